@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	m "github.com/fourcube/goiban-service/metrics"
+)
+
+// Supported access log formats
+const (
+	ACCESS_LOG_COMBINED = "combined"
+	ACCESS_LOG_COMMON   = "common"
+
+	ACCESS_LOG_TIME_FORMAT = "02/Jan/2006:15:04:05 -0700"
+)
+
+// matches IBANs in request paths and query strings
+var ibanInURL = regexp.MustCompile(`\b[A-Za-z]{2}[0-9]{2}[A-Za-z0-9]{8,}\b`)
+
+// Opens the destination of the access log. "-" and "stdout" write to
+// stdout, other values are paths of files the log is appended to.
+func openAccessLog(path string) (*os.File, error) {
+	if path == "-" || path == "stdout" {
+		return os.Stdout, nil
+	}
+
+	return os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+}
+
+// Writes a line per request to out in the common or combined log format.
+// The duration of the request in microseconds is appended to each line.
+func accessLogHandler(out io.Writer, format string, h http.Handler) http.Handler {
+	logger := log.New(out, "", 0)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		lw := &accessLogResponseWriter{ResponseWriter: w, status: http.StatusOK}
+
+		h.ServeHTTP(lw, r)
+
+		logger.Println(accessLogLine(r, lw.status, lw.bytes, start, format))
+	})
+}
+
+// Formats the log line of a request. IBANs in the URL are masked unless
+// GOIBAN_MASK_IBAN is disabled.
+func accessLogLine(r *http.Request, status int, bytes int, start time.Time, format string) string {
+	size := "-"
+	if bytes > 0 {
+		size = strconv.Itoa(bytes)
+	}
+
+	line := fmt.Sprintf("%v - - [%v] \"%v %v %v\" %d %v",
+		clientIP(r), start.Format(ACCESS_LOG_TIME_FORMAT), r.Method, maskedRequestURI(r), r.Proto, status, size)
+
+	if format == ACCESS_LOG_COMBINED {
+		line += fmt.Sprintf(" %q %q", headerOrDash(r, "Referer"), headerOrDash(r, "User-Agent"))
+	}
+
+	return line + " " + strconv.FormatInt(int64(time.Since(start)/time.Microsecond), 10)
+}
+
+// Returns the request URI with the IBANs masked. Path segments and query
+// parameters are decoded and normalized like the input of validate before
+// they are matched, so spaced, lower case and dashed IBANs are masked too.
+// Parts without IBANs are kept as they were requested.
+func maskedRequestURI(r *http.Request) string {
+	if !m.MaskIbans || r.URL.Opaque != "" {
+		return r.URL.RequestURI()
+	}
+
+	segments := strings.Split(r.URL.EscapedPath(), "/")
+	for i, segment := range segments {
+		segments[i] = maskEscaped(segment, url.PathUnescape, url.PathEscape)
+	}
+
+	uri := strings.Join(segments, "/")
+	if uri == "" {
+		uri = "/"
+	}
+
+	if r.URL.RawQuery == "" {
+		return uri
+	}
+
+	params := strings.Split(r.URL.RawQuery, "&")
+	for i, param := range params {
+		pair := strings.SplitN(param, "=", 2)
+		for j, part := range pair {
+			pair[j] = maskEscaped(part, url.QueryUnescape, url.QueryEscape)
+		}
+		params[i] = strings.Join(pair, "=")
+	}
+
+	return uri + "?" + strings.Join(params, "&")
+}
+
+// Masks the IBANs of an escaped part of a URL
+func maskEscaped(escaped string, unescape func(string) (string, error), escape func(string) string) string {
+	value, err := unescape(escaped)
+	if err != nil {
+		value = escaped
+	}
+
+	normalized := normalizeIban(strings.Replace(value, "-", "", -1))
+	if !ibanInURL.MatchString(normalized) {
+		return escaped
+	}
+
+	// the asterisks of the mask are readable in the log without escaping
+	masked := escape(ibanInURL.ReplaceAllStringFunc(normalized, m.MaskIban))
+	return strings.Replace(masked, "%2A", "*", -1)
+}
+
+func headerOrDash(r *http.Request, name string) string {
+	value := r.Header.Get(name)
+	if value == "" {
+		return "-"
+	}
+
+	return value
+}
+
+// accessLogResponseWriter records the status and size of a response
+type accessLogResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func (lw *accessLogResponseWriter) WriteHeader(status int) {
+	if !lw.wroteHeader {
+		lw.status = status
+		lw.wroteHeader = true
+	}
+
+	lw.ResponseWriter.WriteHeader(status)
+}
+
+func (lw *accessLogResponseWriter) Write(p []byte) (int, error) {
+	lw.wroteHeader = true
+
+	n, err := lw.ResponseWriter.Write(p)
+	lw.bytes += n
+	return n, err
+}
+
+// Flush passes flushes of streamed responses on
+func (lw *accessLogResponseWriter) Flush() {
+	if flusher, ok := lw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestAccessLogCombinedFormat(t *testing.T) {
+	var out bytes.Buffer
+	handler := accessLogHandler(&out, ACCESS_LOG_COMBINED, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("hello"))
+	}))
+
+	r := httptest.NewRequest("GET", "/validate/DE89370400440532013000?getBIC=true", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	r.Header.Set("User-Agent", "curl/7.0")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	line := strings.TrimSpace(out.String())
+	expected := regexp.MustCompile(`^192\.0\.2\.1 - - \[[^\]]+\] "GET /validate/DE89\*{16}00\?getBIC=true HTTP/1\.1" 418 5 "-" "curl/7\.0" [0-9]+$`)
+	if !expected.MatchString(line) {
+		t.Errorf("unexpected access log line %v", line)
+	}
+}
+
+func TestAccessLogMasksEncodedIbans(t *testing.T) {
+	r := httptest.NewRequest("GET", "/validate/de89%203704%200044%200532%200130%2000?iban=DE89-3704-0044-0532-0130-00&getBIC=true", nil)
+
+	uri := maskedRequestURI(r)
+	if uri != "/validate/DE89****************00?iban=DE89****************00&getBIC=true" {
+		t.Errorf("expected the IBANs to be masked, got %v", uri)
+	}
+
+	if strings.Contains(uri, "3704") || strings.Contains(uri, "0532") {
+		t.Errorf("expected no part of the BBAN in %v", uri)
+	}
+}
+
+func TestAccessLogCommonFormat(t *testing.T) {
+	var out bytes.Buffer
+	handler := accessLogHandler(&out, ACCESS_LOG_COMMON, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	r := httptest.NewRequest("GET", "/countries", nil)
+	r.RemoteAddr = "192.0.2.1:1234"
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	line := strings.TrimSpace(out.String())
+	expected := regexp.MustCompile(`^192\.0\.2\.1 - - \[[^\]]+\] "GET /countries HTTP/1\.1" 200 - [0-9]+$`)
+	if !expected.MatchString(line) {
+		t.Errorf("unexpected access log line %v", line)
+	}
+}
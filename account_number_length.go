@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/fourcube/goiban"
+)
+
+// Reads the accountNumberLength parameter, 0 when it is absent. Returns
+// false when it is set but isn't a positive number.
+func accountNumberLengthParam(r *http.Request) (int, bool) {
+	value := r.FormValue("accountNumberLength")
+	if value == "" {
+		return 0, true
+	}
+
+	length, err := strconv.Atoi(value)
+	if err != nil || length <= 0 {
+		return 0, false
+	}
+
+	return length, true
+}
+
+// Asserts that the account number in the BBAN of the rendered validation
+// result strRes has the expected length, e.g. to catch truncated account
+// numbers. On a mismatch the result becomes invalid, the validity of the
+// IBAN itself is kept in structureValid. Countries with an unknown BBAN
+// structure only get a message that the length wasn't checked.
+func checkAccountNumberLength(strRes string, expected int) string {
+	if expected == 0 {
+		return strRes
+	}
+
+	var result ValidationResponse
+	json.Unmarshal([]byte(strRes), &result)
+
+	iban := normalizeIban(result.Iban)
+	if !goiban.IsParseable(iban).Valid || validateLength(goiban.ExtractCountryCode(iban), iban) != nil {
+		return strRes
+	}
+
+	length, ok := accountNumberLength(iban)
+	if !ok {
+		result.Messages = append(result.Messages, fmt.Sprintf("The account number length can't be checked for %v.", goiban.ExtractCountryCode(iban)))
+	} else if length != expected {
+		if result.StructureValid == nil {
+			structureValid := result.Valid
+			result.StructureValid = &structureValid
+		}
+
+		result.Valid = false
+		result.Messages = append(result.Messages, fmt.Sprintf("Expected an account number of %v characters, got %v.", expected, length))
+
+		if result.ErrorCode == "" {
+			result.ErrorCode = ERROR_ACCOUNT_LENGTH
+		}
+	}
+
+	res, _ := json.MarshalIndent(result, "", "  ")
+	return string(res)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestAccountNumberLength(t *testing.T) {
+	cases := map[int]bool{
+		10: true,
+		12: false,
+	}
+
+	for length, valid := range cases {
+		strRes, err := validate(context.Background(), "DE89370400440532013000", map[string]bool{})
+		if err != nil {
+			t.Errorf("failed to validate %v", err)
+			t.FailNow()
+		}
+
+		var result ValidationResponse
+		json.Unmarshal([]byte(checkAccountNumberLength(strRes, length)), &result)
+
+		if result.Valid != valid {
+			t.Errorf("expected valid %v for the account number length %v, got %v", valid, length, result.Messages)
+		}
+
+		if !valid && (result.ErrorCode != ERROR_ACCOUNT_LENGTH || result.StructureValid == nil || !*result.StructureValid) {
+			t.Errorf("expected a mismatch of a structurally valid IBAN, got %v %v", result.ErrorCode, result.StructureValid)
+		}
+	}
+}
+
+func TestAccountNumberLengthUnknownStructure(t *testing.T) {
+	// the structure of Turkish BBANs is unknown
+	strRes, err := validate(context.Background(), "TR330006100519786457841326", map[string]bool{})
+	if err != nil {
+		t.Errorf("failed to validate %v", err)
+		t.FailNow()
+	}
+
+	var result ValidationResponse
+	json.Unmarshal([]byte(checkAccountNumberLength(strRes, 7)), &result)
+
+	if !result.Valid || len(result.Messages) == 0 {
+		t.Errorf("expected a valid result noting the unchecked length, got %v", result.Messages)
+	}
+}
+
+func TestAccountNumberLengthRejectsInvalidParameter(t *testing.T) {
+	resp, err := http.Get(server.URL + "/validate/DE89370400440532013000?accountNumberLength=-1")
+	if err != nil {
+		t.Errorf("failed to validate %v", err)
+		t.FailNow()
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected HTTP 400, got %v", resp.StatusCode)
+	}
+}
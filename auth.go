@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+type contextKey string
+
+// API_KEY_LABEL is the context key of the label belonging to the API key of a request
+const API_KEY_LABEL contextKey = "apiKeyLabel"
+
+// Wraps h so that requests are only processed when they carry one of the
+// configured API keys in the X-API-Key header. Authentication is disabled
+// when no API keys are configured. With the failure webhook the client IP
+// is put to the context as well.
+func requireAPIKey(h httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		if failures != nil {
+			r = r.WithContext(context.WithValue(r.Context(), CLIENT_IP, clientIP(r)))
+		}
+
+		if len(cfg.APIKeys) == 0 {
+			h(w, r, ps)
+			return
+		}
+
+		label, ok := lookupAPIKey(r.Header.Get("X-API-Key"))
+		if !ok {
+			log.Printf("Rejected request from %v: missing or invalid API key", clientIP(r))
+
+			res, _ := json.MarshalIndent(errorResult(ERROR_UNAUTHORIZED, "Missing or invalid API key.", ""), "", "  ")
+			writeResponse(w, r, http.StatusUnauthorized, res)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), API_KEY_LABEL, label)
+		h(w, r.WithContext(ctx), ps)
+	}
+}
+
+// Returns the label of the API key. All configured keys are compared in
+// constant time so the response time doesn't reveal valid keys.
+func lookupAPIKey(key string) (string, bool) {
+	label, found := "", false
+
+	for configured, configuredLabel := range cfg.APIKeys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(configured)) == 1 {
+			label, found = configuredLabel, true
+		}
+	}
+
+	return label, found && key != ""
+}
+
+// Returns the label of the API key used for the request, empty when
+// authentication is disabled.
+func apiKeyLabel(r *http.Request) string {
+	label, _ := r.Context().Value(API_KEY_LABEL).(string)
+	return label
+}
+
+// Parses a comma-separated list of API keys. Every entry is either a plain
+// key or a label and a key separated by a colon (e.g. "partner:secret").
+func parseAPIKeys(value string) map[string]string {
+	keys := map[string]string{}
+
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		label, key := "", entry
+		if i := strings.Index(entry, ":"); i >= 0 {
+			label, key = entry[:i], entry[i+1:]
+		}
+
+		keys[key] = label
+	}
+
+	return keys
+}
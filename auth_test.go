@@ -0,0 +1,91 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func withAPIKeys(keys map[string]string) func() {
+	original := cfg.APIKeys
+	cfg.APIKeys = keys
+
+	return func() { cfg.APIKeys = original }
+}
+
+func TestRequireAPIKey(t *testing.T) {
+	defer withAPIKeys(parseAPIKeys("partner:secret"))()
+
+	var label string
+	handler := requireAPIKey(func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		label = apiKeyLabel(r)
+	})
+
+	cases := map[string]int{
+		"secret": http.StatusOK,
+		"wrong":  http.StatusUnauthorized,
+		"":       http.StatusUnauthorized,
+	}
+
+	for key, expected := range cases {
+		req := httptest.NewRequest("GET", "/validate/DE89370400440532013000", nil)
+		req.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+
+		handler(w, req, nil)
+
+		if w.Code != expected {
+			t.Errorf("expected status %v for key %q, got %v", expected, key, w.Code)
+		}
+	}
+
+	if label != "partner" {
+		t.Errorf("expected label partner, got %q", label)
+	}
+}
+
+func TestRejectedRequestIsLocalized(t *testing.T) {
+	defer withAPIKeys(parseAPIKeys("partner:secret"))()
+
+	handler := requireAPIKey(func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {})
+
+	req := httptest.NewRequest("GET", "/validate/DE89370400440532013000?format=xml", nil)
+	req.Header.Set("Accept-Language", "de-DE")
+	w := httptest.NewRecorder()
+
+	handler(w, req, nil)
+
+	if w.Code != http.StatusUnauthorized || w.Header().Get("Content-Type") != contentTypes[FORMAT_XML] {
+		t.Errorf("expected an XML error with HTTP 401, got %v %v", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	if !strings.Contains(w.Body.String(), "Fehlender oder ungültiger API-Schlüssel.") {
+		t.Errorf("expected a German message, got %v", w.Body.String())
+	}
+}
+
+func TestAuthenticationDisabledWithoutKeys(t *testing.T) {
+	defer withAPIKeys(nil)()
+
+	called := false
+	handler := requireAPIKey(func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		called = true
+	})
+
+	handler(httptest.NewRecorder(), httptest.NewRequest("GET", "/validate/DE89370400440532013000", nil), nil)
+
+	if !called {
+		t.Errorf("expected handler to be called")
+	}
+}
+
+func TestParseAPIKeys(t *testing.T) {
+	keys := parseAPIKeys("partner:secret, plain ,")
+
+	if len(keys) != 2 || keys["secret"] != "partner" || keys["plain"] != "" {
+		t.Errorf("unexpected keys %v", keys)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/fourcube/goiban"
+)
+
+// SELECT_BANK_ADDRESS reads the address columns of a bank. The bank data
+// doesn't contain streets, only the zip code and the city are available.
+const SELECT_BANK_ADDRESS = "SELECT zip, city FROM BANK_DATA WHERE bankcode = ? AND country = ? LIMIT 1"
+
+// Adds the zip code and city of the bank to the bank data of result. Null
+// columns are left empty, so they are omitted from the response.
+func addBankAddress(ctx context.Context, countryCode string, result *goiban.ValidationResult) error {
+	if result.BankData.BankCode == "" {
+		return nil
+	}
+
+	var zip, city sql.NullString
+	err := withDBRetry(func() error {
+		ctx, cancel := lookupContext(ctx)
+		defer cancel()
+
+		return db.QueryRowContext(ctx, SELECT_BANK_ADDRESS, result.BankData.BankCode, countryCode).Scan(&zip, &city)
+	})
+
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err == context.DeadlineExceeded {
+		return errDBTimeout
+	}
+	if err != nil {
+		return schemaError(err)
+	}
+
+	result.BankData.Zip = zip.String
+	result.BankData.City = city.String
+	return nil
+}
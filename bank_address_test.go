@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fourcube/goiban"
+)
+
+func TestAddBankAddressWithoutBankCode(t *testing.T) {
+	result := goiban.NewValidationResult(true, "", "DE89370400440532013000")
+
+	// nothing is looked up without a bank code
+	if err := addBankAddress(context.Background(), "DE", result); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	if result.BankData.Zip != "" || result.BankData.City != "" {
+		t.Errorf("unexpected address %v", result.BankData)
+	}
+}
+
+func TestAddBankAddress(t *testing.T) {
+	if db.Ping() != nil {
+		t.Skip("database is not available")
+	}
+
+	result := goiban.NewValidationResult(true, "", "DE89370400440532013000")
+	result.BankData.BankCode = "37040044"
+
+	if err := addBankAddress(context.Background(), "DE", result); err != nil {
+		t.Errorf("failed to look up the address %v", err)
+	}
+
+	if result.BankData.City == "" {
+		t.Errorf("expected the city of the bank, got %v", result.BankData)
+	}
+}
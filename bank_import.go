@@ -0,0 +1,238 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	SELECT_BANK_FOR_IMPORT = "SELECT name, bic FROM BANK_DATA WHERE bankcode = ? AND country = ? LIMIT 1"
+	INSERT_BANK            = "INSERT INTO BANK_DATA (bankcode, name, bic, country) VALUES (?, ?, ?, ?)"
+	UPDATE_BANK            = "UPDATE BANK_DATA SET name = ?, bic = ? WHERE bankcode = ? AND country = ?"
+
+	// MAX_IMPORT_SIZE is the maximum number of records of a single import
+	MAX_IMPORT_SIZE = 100000
+
+	// MAX_IMPORT_ERRORS limits the reasons of skipped records in the summary
+	MAX_IMPORT_ERRORS = 100
+)
+
+var bankCodePattern = regexp.MustCompile(`^[A-Z0-9]{1,12}$`)
+
+// BankRecord is an entry of a bank data import
+type BankRecord struct {
+	Country  string `json:"country"`
+	BankCode string `json:"bankCode"`
+	Name     string `json:"name"`
+	Bic      string `json:"bic"`
+}
+
+// ImportSummary is the response of /admin/banks/import. Invalid records
+// and records which are already up to date are skipped, Errors explains
+// why invalid records were skipped.
+type ImportSummary struct {
+	Inserted int      `json:"inserted"`
+	Updated  int      `json:"updated"`
+	Skipped  int      `json:"skipped"`
+	Errors   []string `json:"errors,omitempty"`
+}
+
+// Processes requests to the /admin/banks/import url. Accepts a JSON array
+// of BankRecords or, with Content-Type text/csv, CSV rows of country, bank
+// code, name and BIC with an optional header row. All records are upserted
+// within a single transaction. Only available when API keys are configured.
+func bankImportHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	if len(cfg.APIKeys) == 0 {
+		writeError(w, r, http.StatusForbidden, ERROR_UNAUTHORIZED, "The bank import requires API keys to be configured.")
+		return
+	}
+
+	records, err := readBankRecords(r)
+	r.Body.Close()
+
+	if bodyTooLarge(err) {
+		writeBodyTooLarge(w, r)
+		return
+	}
+
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, ERROR_INVALID_REQUEST, "Expected a JSON array or CSV rows of bank records: "+err.Error())
+		return
+	}
+
+	if len(records) > MAX_IMPORT_SIZE {
+		writeError(w, r, http.StatusRequestEntityTooLarge, ERROR_INVALID_REQUEST, "Import exceeds the maximum size of "+strconv.Itoa(MAX_IMPORT_SIZE)+" records.")
+		return
+	}
+
+	summary, err := importBanks(records)
+	if err != nil {
+		log.Printf("Error while importing bank data: %v", err)
+		writeLookupError(w, r, schemaError(err))
+		return
+	}
+
+	log.Printf("Imported bank data: %d inserted, %d updated, %d skipped", summary.Inserted, summary.Updated, summary.Skipped)
+
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, data)
+}
+
+// Reads the records from the request body as CSV or JSON depending on
+// the content type
+func readBankRecords(r *http.Request) ([]BankRecord, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	if mediaType != "text/csv" && mediaType != "application/csv" {
+		var records []BankRecord
+		err := json.NewDecoder(r.Body).Decode(&records)
+		return records, err
+	}
+
+	reader := csv.NewReader(r.Body)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+
+	var records []BankRecord
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// the header row is optional
+		if len(records) == 0 && strings.EqualFold(row[0], "country") {
+			continue
+		}
+
+		records = append(records, BankRecord{row[0], row[1], row[2], row[3]})
+		if len(records) > MAX_IMPORT_SIZE {
+			return records, nil
+		}
+	}
+}
+
+// Upserts the records within a transaction. The cached bank lists of the
+// imported countries are removed afterwards. Cached validation results
+// keep the previous BIC until they expire.
+func importBanks(records []BankRecord) (*ImportSummary, error) {
+	summary := &ImportSummary{}
+
+	var tx *sql.Tx
+	err := withDBRetry(func() error {
+		var err error
+		tx, err = db.Begin()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	countries := map[string]bool{}
+	for i, record := range records {
+		record = normalizeBankRecord(record)
+		if reason := invalidBankRecord(record); reason != "" {
+			summary.Skipped++
+			if len(summary.Errors) < MAX_IMPORT_ERRORS {
+				summary.Errors = append(summary.Errors, fmt.Sprintf("record %d: %v", i+1, reason))
+			}
+			continue
+		}
+
+		result, err := upsertBank(tx, record)
+		if err != nil {
+			return nil, err
+		}
+
+		switch result {
+		case "inserted":
+			summary.Inserted++
+		case "updated":
+			summary.Updated++
+		default:
+			summary.Skipped++
+		}
+		countries[record.Country] = true
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	for countryCode := range countries {
+		c.Delete("banks:" + countryCode)
+	}
+
+	return summary, nil
+}
+
+// Inserts the bank or updates its name and BIC. Returns "inserted",
+// "updated" or "unchanged".
+func upsertBank(tx *sql.Tx, record BankRecord) (string, error) {
+	bic := sql.NullString{String: record.Bic, Valid: record.Bic != ""}
+
+	var name string
+	var current sql.NullString
+	err := tx.QueryRow(SELECT_BANK_FOR_IMPORT, record.BankCode, record.Country).Scan(&name, &current)
+
+	if err == sql.ErrNoRows {
+		_, err = tx.Exec(INSERT_BANK, record.BankCode, record.Name, bic, record.Country)
+		return "inserted", err
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if name == record.Name && current.String == record.Bic {
+		return "unchanged", nil
+	}
+
+	_, err = tx.Exec(UPDATE_BANK, record.Name, bic, record.BankCode, record.Country)
+	return "updated", err
+}
+
+func normalizeBankRecord(record BankRecord) BankRecord {
+	return BankRecord{
+		Country:  strings.ToUpper(strings.TrimSpace(record.Country)),
+		BankCode: strings.ToUpper(strings.TrimSpace(record.BankCode)),
+		Name:     strings.TrimSpace(record.Name),
+		Bic:      strings.ToUpper(strings.TrimSpace(record.Bic)),
+	}
+}
+
+// Returns why the record can't be imported, or "" if it is valid
+func invalidBankRecord(record BankRecord) string {
+	switch {
+	case !isKnownCountry(record.Country):
+		return "unknown country code " + strconv.Quote(record.Country)
+	case !bankCodePattern.MatchString(record.BankCode):
+		return "invalid bank code " + strconv.Quote(record.BankCode)
+	case record.Name == "":
+		return "missing name"
+	case record.Bic != "" && !bicPattern.MatchString(record.Bic):
+		return "invalid BIC " + strconv.Quote(record.Bic)
+	}
+
+	return ""
+}
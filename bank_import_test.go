@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReadBankRecordsFromCSV(t *testing.T) {
+	body := "country,bankCode,name,bic\nDE, 37040044, Commerzbank, COBADEFFXXX\nBE,539,Bank,\n"
+	r := httptest.NewRequest("POST", "/admin/banks/import", strings.NewReader(body))
+	r.Header.Set("Content-Type", "text/csv; charset=utf-8")
+
+	records, err := readBankRecords(r)
+	if err != nil {
+		t.Errorf("failed to read the records %v", err)
+		t.FailNow()
+	}
+
+	if len(records) != 2 || records[0] != (BankRecord{"DE", "37040044", "Commerzbank", "COBADEFFXXX"}) {
+		t.Errorf("unexpected records %v", records)
+	}
+}
+
+func TestInvalidBankRecord(t *testing.T) {
+	cases := map[BankRecord]bool{
+		{"DE", "37040044", "Commerzbank", "COBADEFFXXX"}: true,
+		{"DE", "37040044", "Commerzbank", ""}:            true,
+		{"XX", "37040044", "Commerzbank", ""}:            false,
+		{"DE", "", "Commerzbank", ""}:                    false,
+		{"DE", "37040044", "", ""}:                       false,
+		{"DE", "37040044", "Commerzbank", "COBADE"}:      false,
+	}
+
+	for record, valid := range cases {
+		if reason := invalidBankRecord(record); (reason == "") != valid {
+			t.Errorf("expected valid to be %v for %v, got %v", valid, record, reason)
+		}
+	}
+}
+
+func TestBankImportRequiresAPIKeys(t *testing.T) {
+	resp, err := http.Post(server.URL+"/admin/banks/import", "application/json", strings.NewReader("[]"))
+	if err != nil {
+		t.Errorf("failed to import %v", err)
+		t.FailNow()
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("expected HTTP 403 without API keys, got %v", resp.StatusCode)
+	}
+}
+
+func TestBankImportUpserts(t *testing.T) {
+	if db.Ping() != nil {
+		t.Skip("database is not available")
+	}
+
+	defer func(keys map[string]string) { cfg.APIKeys = keys }(cfg.APIKeys)
+	cfg.APIKeys = map[string]string{"secret": "ops"}
+	defer db.Exec("DELETE FROM BANK_DATA WHERE bankcode = ? AND country = ?", "99999999", "DE")
+
+	imports := []struct {
+		body     string
+		expected ImportSummary
+	}{
+		{`[{"country":"DE","bankCode":"99999999","name":"Test Bank","bic":"TESTDEFF"},{"country":"XX","bankCode":"1","name":"x"}]`, ImportSummary{Inserted: 1, Skipped: 1}},
+		{`[{"country":"DE","bankCode":"99999999","name":"Test Bank","bic":"TESTDEFFXXX"}]`, ImportSummary{Updated: 1}},
+		{`[{"country":"DE","bankCode":"99999999","name":"Test Bank","bic":"TESTDEFFXXX"}]`, ImportSummary{Skipped: 1}},
+	}
+
+	for _, imp := range imports {
+		resp, err := http.Post(server.URL+"/admin/banks/import", "application/json", strings.NewReader(imp.body))
+		if err != nil {
+			t.Errorf("failed to import %v", err)
+			t.FailNow()
+		}
+
+		var summary ImportSummary
+		json.NewDecoder(resp.Body).Decode(&summary)
+		resp.Body.Close()
+
+		if summary.Inserted != imp.expected.Inserted || summary.Updated != imp.expected.Updated || summary.Skipped != imp.expected.Skipped {
+			t.Errorf("expected %v for %v, got %v", imp.expected, imp.body, summary)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fourcube/goiban"
+	"github.com/julienschmidt/httprouter"
+)
+
+const (
+	SELECT_BANKS_BY_COUNTRY = "SELECT bankcode, name, bic FROM BANK_DATA WHERE country = ? ORDER BY bankcode"
+
+	// BANKS_CACHE_TTL is the expiration of the cached bank lists, they rarely change
+	BANKS_CACHE_TTL = 24 * time.Hour
+
+	DEFAULT_BANKS_LIMIT = 100
+	MAX_BANKS_LIMIT     = 1000
+)
+
+// Bank is an entry of the bank list of a country
+type Bank struct {
+	BankCode string `json:"bankCode"`
+	Name     string `json:"name"`
+	Bic      string `json:"bic"`
+}
+
+// Processes requests to the /banks/ url. Supports pagination through
+// the limit and offset parameters, the total number of banks is returned
+// in the X-Total-Count header.
+func banksHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Allow CORS
+	allowOrigin(w, r)
+
+	countryCode := strings.ToUpper(ps.ByName("countryCode"))
+	if !isKnownCountry(countryCode) {
+		writeError(w, r, http.StatusBadRequest, ERROR_UNKNOWN_COUNTRY, "Unknown country code.")
+		return
+	}
+
+	limit, err := queryInt(r, "limit", DEFAULT_BANKS_LIMIT)
+	if err != nil || limit < 0 || limit > MAX_BANKS_LIMIT {
+		writeError(w, r, http.StatusBadRequest, ERROR_INVALID_REQUEST, "Expected a limit between 0 and "+strconv.Itoa(MAX_BANKS_LIMIT)+".")
+		return
+	}
+
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil || offset < 0 {
+		writeError(w, r, http.StatusBadRequest, ERROR_INVALID_REQUEST, "Expected a positive offset.")
+		return
+	}
+
+	banks, err := listBanks(countryCode)
+	if err != nil {
+		log.Printf("Error while listing the banks of %v: %v", countryCode, err)
+		writeLookupError(w, r, err)
+		return
+	}
+
+	total := len(banks)
+	if offset > total {
+		offset = total
+	}
+	if offset+limit < total {
+		banks = banks[offset : offset+limit]
+	} else {
+		banks = banks[offset:]
+	}
+
+	data, err := json.MarshalIndent(banks, "", "  ")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	writeResponse(w, r, http.StatusOK, data)
+}
+
+// Returns all banks of the country. The list is cached for BANKS_CACHE_TTL.
+func listBanks(countryCode string) ([]Bank, error) {
+	key := "banks:" + countryCode
+	banks := []Bank{}
+
+	value, found := c.Get(key)
+	if found && json.Unmarshal([]byte(value), &banks) == nil {
+		return banks, nil
+	}
+
+	err := withDBRetry(func() error {
+		var err error
+		banks, err = queryBanks(countryCode)
+		return err
+	})
+	if err != nil {
+		return nil, schemaError(err)
+	}
+
+	data, err := json.Marshal(banks)
+	if err == nil {
+		c.Set(key, string(data), BANKS_CACHE_TTL)
+	}
+
+	return banks, nil
+}
+
+// Reads the banks of the country from the database
+func queryBanks(countryCode string) ([]Bank, error) {
+	banks := []Bank{}
+
+	rows, err := db.Query(SELECT_BANKS_BY_COUNTRY, countryCode)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bank Bank
+		var bic sql.NullString
+
+		err = rows.Scan(&bank.BankCode, &bank.Name, &bic)
+		if err != nil {
+			return nil, err
+		}
+
+		bank.Bic = bic.String
+		banks = append(banks, bank)
+	}
+
+	return banks, rows.Err()
+}
+
+// Returns true when countryCode is one of the countries returned by /countries
+func isKnownCountry(countryCode string) bool {
+	for _, code := range goiban.COUNTRY_TO_CC_MAP {
+		if code == countryCode {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Parses the query parameter name as an integer, returns fallback when
+// the parameter is missing.
+func queryInt(r *http.Request, name string, fallback int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+
+	return strconv.Atoi(value)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestBanksUnknownCountry(t *testing.T) {
+	resp, err := http.Get(server.URL + "/banks/XX")
+
+	if err != nil {
+		t.Errorf("failed to list banks %v", err)
+		t.FailNow()
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status %v, got %v", http.StatusBadRequest, resp.StatusCode)
+	}
+}
+
+func TestBanksInvalidLimit(t *testing.T) {
+	resp, err := http.Get(server.URL + "/banks/DE?limit=abc")
+
+	if err != nil {
+		t.Errorf("failed to list banks %v", err)
+		t.FailNow()
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status %v, got %v", http.StatusBadRequest, resp.StatusCode)
+	}
+}
+
+func TestBanksPagination(t *testing.T) {
+	c.Set("banks:DE", `[{"bankCode":"1"},{"bankCode":"2"},{"bankCode":"3"}]`, 0)
+	defer c.Delete("banks:DE")
+
+	resp, err := http.Get(server.URL + "/banks/de?limit=2&offset=1")
+
+	if err != nil {
+		t.Errorf("failed to list banks %v", err)
+		t.FailNow()
+	}
+
+	var banks []Bank
+	json.NewDecoder(resp.Body).Decode(&banks)
+	resp.Body.Close()
+
+	if resp.Header.Get("X-Total-Count") != "3" {
+		t.Errorf("expected a total of 3 banks, got %v", resp.Header.Get("X-Total-Count"))
+	}
+
+	if len(banks) != 2 || banks[0].BankCode != "2" || banks[1].BankCode != "3" {
+		t.Errorf("unexpected page %v", banks)
+	}
+}
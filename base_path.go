@@ -0,0 +1,27 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Mounts h under basePath (e.g. "/iban-api"), so "/iban-api/validate/..."
+// is served as "/validate/...". Requests outside of basePath are not found.
+func mountAt(basePath string, h http.Handler) http.Handler {
+	basePath = "/" + strings.Trim(basePath, "/")
+	if basePath == "/" {
+		return h
+	}
+
+	stripped := http.StripPrefix(basePath, h)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == basePath:
+			http.Redirect(w, r, basePath+"/", http.StatusMovedPermanently)
+		case strings.HasPrefix(r.URL.Path, basePath+"/"):
+			stripped.ServeHTTP(w, r)
+		default:
+			notFoundHandler(w, r)
+		}
+	})
+}
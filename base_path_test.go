@@ -0,0 +1,35 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMountAt(t *testing.T) {
+	h := mountAt("/iban-api/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	}))
+
+	cases := map[string]int{
+		"/iban-api/validate/DE89370400440532013000": http.StatusOK,
+		"/iban-api":                        http.StatusMovedPermanently,
+		"/validate/DE89370400440532013000": http.StatusNotFound,
+		"/iban-apivalidate":                http.StatusNotFound,
+	}
+
+	for path, status := range cases {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+
+		if rec.Code != status {
+			t.Errorf("expected status %v for %v, got %v", status, path, rec.Code)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/iban-api/countries", nil))
+	if rec.Body.String() != "/countries" {
+		t.Errorf("expected the base path to be stripped, got %v", rec.Body.String())
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	m "github.com/fourcube/goiban-service/metrics"
+	"github.com/julienschmidt/httprouter"
+)
+
+// MAX_BATCH_SIZE is the maximum number of IBANs accepted by a single batch request
+const MAX_BATCH_SIZE = 1000
+
+// MAX_LIST_SIZE is the maximum number of comma-separated IBANs accepted by GET /validate
+const MAX_LIST_SIZE = 10
+
+// Processes requests to the /validate/batch url. The results are streamed
+// to the client as they are computed when the ResponseWriter supports
+// flushing, otherwise the response is buffered. With format=ndjson every
+// result is written as a line of its own instead of a JSON array.
+func batchValidationHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	w.Header().Add("Content-Type", "application/json; charset=utf-8")
+	// Allow CORS
+	allowOrigin(w, r)
+
+	var ibans []string
+	err := json.NewDecoder(r.Body).Decode(&ibans)
+	r.Body.Close()
+
+	if bodyTooLarge(err) {
+		writeBodyTooLarge(w, r)
+		return
+	}
+
+	if err != nil {
+		res, _ := json.MarshalIndent(errorResult(ERROR_INVALID_REQUEST, "Expected a JSON array of IBANs.", ""), "", "  ")
+		writeResponse(w, r, http.StatusBadRequest, res)
+		return
+	}
+
+	if len(ibans) > MAX_BATCH_SIZE {
+		res, _ := json.MarshalIndent(errorResult(ERROR_TOO_MANY_IBANS, "Batch exceeds the maximum size of "+strconv.Itoa(MAX_BATCH_SIZE)+" IBANs.", ""), "", "  ")
+		writeResponse(w, r, http.StatusRequestEntityTooLarge, res)
+		return
+	}
+
+	config := validationConfig(r)
+
+	// the first entry is validated before the response is started, so an
+	// unavailable database is still answered with HTTP 503
+	first := ""
+	if len(ibans) > 0 {
+		first, err = validate(r.Context(), ibans[0], config)
+		if err != nil {
+			result, status := lookupError(err, ibans[0])
+			res, _ := json.MarshalIndent(result, "", "  ")
+			writeResponse(w, r, status, res)
+			return
+		}
+		first = string(selectFields([]byte(first), r))
+	}
+
+	write := writeResults
+	if r.URL.Query().Get("format") == FORMAT_NDJSON {
+		w.Header().Set("Content-Type", contentTypes[FORMAT_NDJSON])
+		write = writeNDJSONResults
+	}
+
+	flusher, streaming := w.(http.Flusher)
+	if !streaming {
+		var buf bytes.Buffer
+		write(r, &buf, func() {}, ibans, first, config)
+
+		w.Header().Add("Content-Length", strconv.Itoa(buf.Len()))
+		w.WriteHeader(http.StatusOK)
+		w.Write(buf.Bytes())
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	write(r, w, flusher.Flush, ibans, first, config)
+}
+
+// Writes the results of the batch as a JSON array, calling flush after
+// every entry. first is the result of the first IBAN. The response has
+// already been started when later entries are validated, an entry whose
+// bank data can't be looked up becomes a DB_UNAVAILABLE or DB_TIMEOUT result.
+func writeResults(r *http.Request, out io.Writer, flush func(), ibans []string, first string, config map[string]bool) {
+	if len(ibans) == 0 {
+		io.WriteString(out, "[]")
+		return
+	}
+
+	io.WriteString(out, "[\n")
+	for i, iban := range ibans {
+		strRes := first
+		if i > 0 {
+			strRes = validateEntry(r, iban, config)
+			io.WriteString(out, ",\n")
+		}
+
+		// same layout as json.MarshalIndent of the whole array
+		var entry bytes.Buffer
+		json.Indent(&entry, []byte(strRes), "  ", "  ")
+		io.WriteString(out, "  ")
+		out.Write(entry.Bytes())
+		flush()
+	}
+	io.WriteString(out, "\n]")
+	flush()
+}
+
+// Writes the results of the batch as newline-delimited JSON, one compact
+// result per line, calling flush after every entry. Like writeResults but
+// without the enclosing array, so clients can process the results line by
+// line as they arrive.
+func writeNDJSONResults(r *http.Request, out io.Writer, flush func(), ibans []string, first string, config map[string]bool) {
+	for i, iban := range ibans {
+		strRes := first
+		if i > 0 {
+			strRes = validateEntry(r, iban, config)
+		}
+
+		var entry bytes.Buffer
+		json.Compact(&entry, []byte(strRes))
+		entry.WriteByte('\n')
+		out.Write(entry.Bytes())
+		flush()
+	}
+}
+
+// Validates a later entry of a batch and selects the fields requested by
+// r. The response has already been started, so a failed bank data lookup
+// becomes the error result.
+func validateEntry(r *http.Request, iban string, config map[string]bool) string {
+	strRes, err := validate(r.Context(), iban, config)
+	if err != nil {
+		log.Printf("Error while validating %v: %v", m.SafeIban(iban), err)
+		result, _ := lookupError(err, iban)
+		res, _ := json.Marshal(result)
+		strRes = string(res)
+	}
+
+	return string(selectFields([]byte(strRes), r))
+}
+
+// Processes GET /validate requests with a comma-separated list of IBANs
+func listValidationHandler(w http.ResponseWriter, r *http.Request, list string) {
+	ibans := strings.SplitN(list, ",", MAX_LIST_SIZE+1)
+
+	if len(ibans) > MAX_LIST_SIZE {
+		res, _ := json.MarshalIndent(errorResult(ERROR_TOO_MANY_IBANS, "List exceeds the maximum size of "+strconv.Itoa(MAX_LIST_SIZE)+" IBANs.", ""), "", "  ")
+		writeResponse(w, r, http.StatusBadRequest, res)
+		return
+	}
+
+	for _, iban := range ibans {
+		if exceedsMaxLength(iban) {
+			writeTooLong(w, r)
+			return
+		}
+	}
+
+	results, failed, err := validateAll(r.Context(), ibans, validationConfig(r))
+	if err != nil {
+		result, status := lookupError(err, failed)
+		res, _ := json.MarshalIndent(result, "", "  ")
+		writeResponse(w, r, status, res)
+		return
+	}
+
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, data)
+}
+
+// Validates every IBAN in order. validate reuses the cache and logs the
+// metrics for every entry. When the bank data of an entry can't be looked
+// up, that entry and the error are returned.
+func validateAll(ctx context.Context, ibans []string, config map[string]bool) ([]json.RawMessage, string, error) {
+	results := make([]json.RawMessage, len(ibans))
+	for i, iban := range ibans {
+		strRes, err := validate(ctx, iban, config)
+
+		if err != nil {
+			return nil, iban, err
+		}
+
+		results[i] = json.RawMessage(strRes)
+	}
+
+	return results, "", nil
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/fourcube/goiban"
+)
+
+func TestBatchValidationKeepsOrder(t *testing.T) {
+	body := `["DE89370400440532013000", "DE89370400440532013001", "DE89370400440532013000"]`
+	resp, err := http.Post(server.URL+"/validate/batch", "application/json", strings.NewReader(body))
+
+	if err != nil {
+		t.Errorf("failed to validate batch %v", err)
+		t.FailNow()
+	}
+
+	var res []goiban.ValidationResult
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	resp.Body.Close()
+
+	if err != nil {
+		t.Errorf("Expected success %v", err)
+		t.FailNow()
+	}
+
+	if len(res) != 3 {
+		t.Fatalf("expected 3 results, got %v", len(res))
+	}
+
+	if !res[0].Valid || res[1].Valid || !res[2].Valid {
+		t.Errorf("unexpected batch result %v", res)
+	}
+
+	if res[1].Iban != "DE89370400440532013001" {
+		t.Errorf("expected results in request order, got %v", res[1].Iban)
+	}
+}
+
+func TestBatchValidationRejectsInvalidBody(t *testing.T) {
+	resp, err := http.Post(server.URL+"/validate/batch", "application/json", strings.NewReader(`{"iban":"DE89370400440532013000"}`))
+
+	if err != nil {
+		t.Errorf("failed to validate batch %v", err)
+		t.FailNow()
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status %v, got %v", http.StatusBadRequest, resp.StatusCode)
+	}
+
+	if resp.Header.Get("Content-Type") != contentTypes[FORMAT_JSON] {
+		t.Errorf("expected a JSON error, got %v", resp.Header.Get("Content-Type"))
+	}
+
+	// errors are written in the requested format
+	resp, err = http.Post(server.URL+"/validate/batch?format=xml", "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Errorf("failed to validate batch %v", err)
+		t.FailNow()
+	}
+	resp.Body.Close()
+
+	if resp.Header.Get("Content-Type") != contentTypes[FORMAT_XML] {
+		t.Errorf("expected an XML error, got %v", resp.Header.Get("Content-Type"))
+	}
+}
+
+func TestBatchValidationTooLarge(t *testing.T) {
+	ibans := make([]string, MAX_BATCH_SIZE+1)
+	for i := range ibans {
+		ibans[i] = "DE89370400440532013000"
+	}
+	body, _ := json.Marshal(ibans)
+
+	resp, err := http.Post(server.URL+"/validate/batch", "application/json", strings.NewReader(string(body)))
+
+	if err != nil {
+		t.Errorf("failed to validate batch %v", err)
+		t.FailNow()
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected status %v, got %v", http.StatusRequestEntityTooLarge, resp.StatusCode)
+	}
+}
+
+func TestCommaSeparatedValidation(t *testing.T) {
+	resp, err := http.Get(server.URL + "/validate/DE89370400440532013000,DE89370400440532013001")
+
+	if err != nil {
+		t.Errorf("failed to validate list %v", err)
+		t.FailNow()
+	}
+
+	var res []goiban.ValidationResult
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	resp.Body.Close()
+
+	if err != nil {
+		t.Errorf("Expected an array of results %v", err)
+		t.FailNow()
+	}
+
+	if len(res) != 2 || !res[0].Valid || res[1].Valid {
+		t.Errorf("unexpected list result %v", res)
+	}
+}
+
+func TestCommaSeparatedValidationRejectsLongLists(t *testing.T) {
+	list := strings.TrimSuffix(strings.Repeat("DE89370400440532013000,", MAX_LIST_SIZE+1), ",")
+	resp, err := http.Get(server.URL + "/validate/" + list)
+
+	if err != nil {
+		t.Errorf("failed to validate list %v", err)
+		t.FailNow()
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status %v, got %v", http.StatusBadRequest, resp.StatusCode)
+	}
+}
+
+func TestBatchValidationStreamsResults(t *testing.T) {
+	ibans := []string{"DE89370400440532013000", "GB82WEST12345698765432", "XX"}
+	body, _ := json.Marshal(ibans)
+
+	results, _, err := validateAll(context.Background(), ibans, map[string]bool{})
+	if err != nil {
+		t.Errorf("failed to validate batch %v", err)
+		t.FailNow()
+	}
+	expected, _ := json.MarshalIndent(results, "", "  ")
+
+	// the recorder supports flushing, the wrapped one doesn't
+	streamed := httptest.NewRecorder()
+	buffered := httptest.NewRecorder()
+	batchValidationHandler(streamed, httptest.NewRequest("POST", "/validate/batch", bytes.NewReader(body)), nil)
+	batchValidationHandler(struct{ http.ResponseWriter }{buffered}, httptest.NewRequest("POST", "/validate/batch", bytes.NewReader(body)), nil)
+
+	if !streamed.Flushed || streamed.Body.String() != string(expected) {
+		t.Errorf("unexpected streamed response %v", streamed.Body.String())
+	}
+
+	if buffered.Body.String() != string(expected) || buffered.Header().Get("Content-Length") != strconv.Itoa(len(expected)) {
+		t.Errorf("unexpected buffered response %v", buffered.Body.String())
+	}
+}
+
+func TestBatchValidationNDJSON(t *testing.T) {
+	ibans := []string{"DE89370400440532013000", "DE89370400440532013001", "XX"}
+	body, _ := json.Marshal(ibans)
+
+	resp := httptest.NewRecorder()
+	batchValidationHandler(resp, httptest.NewRequest("POST", "/validate/batch?format=ndjson", bytes.NewReader(body)), nil)
+
+	if resp.Header().Get("Content-Type") != "application/x-ndjson" {
+		t.Errorf("unexpected content type %v", resp.Header().Get("Content-Type"))
+	}
+
+	if !resp.Flushed {
+		t.Errorf("expected the results to be flushed")
+	}
+
+	lines := strings.Split(strings.TrimSuffix(resp.Body.String(), "\n"), "\n")
+	if len(lines) != len(ibans) {
+		t.Fatalf("expected %v lines, got %q", len(ibans), resp.Body.String())
+	}
+
+	for i, line := range lines {
+		var result goiban.ValidationResult
+		err := json.Unmarshal([]byte(line), &result)
+		if err != nil {
+			t.Errorf("failed to decode line %v: %v", i, err)
+			continue
+		}
+
+		if result.Iban != ibans[i] || result.Valid != (i == 0) {
+			t.Errorf("unexpected result in line %v: %v", i, line)
+		}
+	}
+}
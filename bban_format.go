@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/fourcube/goiban"
+)
+
+// BBAN_FORMATS maps country codes to the BBAN format of the SWIFT IBAN
+// registry. Each part is a length, "!" for a fixed length and the type of
+// the characters: n for digits, a for upper case letters and c for letters
+// and digits. The French overseas territories use the format of FR.
+var BBAN_FORMATS = map[string]string{
+	"AD": "4!n4!n12!c", "AE": "3!n16!n", "AL": "8!n16!c", "AT": "5!n11!n",
+	"AZ": "4!a20!c", "BA": "3!n3!n8!n2!n", "BE": "3!n7!n2!n", "BG": "4!a4!n2!n8!c",
+	"BH": "4!a14!c", "BI": "5!n5!n11!n2!n", "BL": "5!n5!n11!c2!n", "BR": "8!n5!n10!n1!a1!c",
+	"BY": "4!c4!n16!c", "CH": "5!n12!c", "CR": "4!n14!n", "CY": "3!n5!n16!c",
+	"CZ": "4!n6!n10!n", "DE": "8!n10!n", "DJ": "5!n5!n11!n2!n", "DK": "4!n9!n1!n",
+	"DO": "4!c20!n", "EE": "2!n2!n11!n1!n", "EG": "4!n4!n17!n", "ES": "4!n4!n1!n1!n10!n",
+	"FI": "3!n11!n", "FK": "2!a12!n", "FO": "4!n9!n1!n", "FR": "5!n5!n11!c2!n",
+	"GB": "4!a6!n8!n", "GE": "2!a16!n", "GF": "5!n5!n11!c2!n", "GI": "4!a15!c",
+	"GL": "4!n9!n1!n", "GP": "5!n5!n11!c2!n", "GR": "3!n4!n16!c", "GT": "4!c20!c",
+	"HR": "7!n10!n", "HU": "3!n4!n1!n15!n1!n", "IE": "4!a6!n8!n", "IL": "3!n3!n13!n",
+	"IQ": "4!a3!n12!n", "IS": "4!n2!n6!n10!n", "IT": "1!a5!n5!n12!c", "JO": "4!a4!n18!c",
+	"KW": "4!a22!c", "KZ": "3!n13!c", "LB": "4!n20!c", "LC": "4!a24!c",
+	"LI": "5!n12!c", "LT": "5!n11!n", "LU": "3!n13!c", "LV": "4!a13!c",
+	"LY": "3!n3!n15!n", "MC": "5!n5!n11!c2!n", "MD": "2!c18!c", "ME": "3!n13!n2!n",
+	"MF": "5!n5!n11!c2!n", "MK": "3!n10!c2!n", "MN": "4!n12!n", "MQ": "5!n5!n11!c2!n",
+	"MR": "5!n5!n11!n2!n", "MT": "4!a5!n18!c", "MU": "4!a2!n2!n12!n3!n3!a", "NC": "5!n5!n11!c2!n",
+	"NI": "4!a20!n", "NL": "4!a10!n", "NO": "4!n6!n1!n", "OM": "3!n16!c",
+	"PF": "5!n5!n11!c2!n", "PK": "4!a16!c", "PL": "8!n16!n", "PM": "5!n5!n11!c2!n",
+	"PS": "4!a21!c", "PT": "4!n4!n11!n2!n", "QA": "4!a21!c", "RE": "5!n5!n11!c2!n",
+	"RO": "4!a16!c", "RS": "3!n13!n2!n", "RU": "9!n5!n15!c", "SA": "2!n18!c",
+	"SC": "4!a2!n2!n16!n3!a", "SD": "2!n12!n", "SE": "3!n16!n1!n", "SI": "5!n8!n2!n",
+	"SK": "4!n6!n10!n", "SM": "1!a5!n5!n12!c", "SO": "4!n3!n12!n", "ST": "4!n4!n11!n2!n",
+	"SV": "4!a20!n", "TF": "5!n5!n11!c2!n", "TL": "3!n14!n2!n", "TN": "2!n3!n13!n2!n",
+	"TR": "5!n1!n16!c", "UA": "6!n19!c", "VA": "3!n15!n", "VG": "4!a16!n",
+	"WF": "5!n5!n11!c2!n", "XK": "4!n10!n2!n", "YE": "4!a4!n18!c", "YT": "5!n5!n11!c2!n",
+}
+
+// bbanFormatClasses are the character classes of the registry types
+var bbanFormatClasses = map[byte]string{
+	'n': "[0-9]",
+	'a': "[A-Z]",
+	'c': "[A-Z0-9]",
+}
+
+var bbanFormatPatterns = compileBbanFormats(BBAN_FORMATS)
+
+// Compiles the registry formats into regular expressions matching the
+// complete BBAN, e.g. "8!n10!n" into ^[0-9]{8}[0-9]{10}$. Panics on a
+// malformed format, like regexp.MustCompile.
+func compileBbanFormats(formats map[string]string) map[string]*regexp.Regexp {
+	patterns := make(map[string]*regexp.Regexp, len(formats))
+
+	for countryCode, format := range formats {
+		pattern, err := bbanFormatPattern(format)
+		if err != nil {
+			panic(fmt.Sprintf("BBAN format of %v: %v", countryCode, err))
+		}
+		patterns[countryCode] = regexp.MustCompile(pattern)
+	}
+
+	return patterns
+}
+
+// Returns the regular expression of a registry format. A part without "!"
+// has up to the given number of characters.
+func bbanFormatPattern(format string) (string, error) {
+	pattern := "^"
+
+	for i := 0; i < len(format); {
+		start := i
+		for i < len(format) && format[i] >= '0' && format[i] <= '9' {
+			i++
+		}
+
+		length, err := strconv.Atoi(format[start:i])
+		if err != nil {
+			return "", fmt.Errorf("expected a length at %d of %q", start, format)
+		}
+
+		fixed := i < len(format) && format[i] == '!'
+		if fixed {
+			i++
+		}
+
+		if i >= len(format) || bbanFormatClasses[format[i]] == "" {
+			return "", fmt.Errorf("expected n, a or c at %d of %q", i, format)
+		}
+		class := bbanFormatClasses[format[i]]
+		i++
+
+		if fixed {
+			pattern += fmt.Sprintf("%v{%d}", class, length)
+		} else {
+			pattern += fmt.Sprintf("%v{1,%d}", class, length)
+		}
+	}
+
+	return pattern + "$", nil
+}
+
+// Checks the BBAN of an IBAN of valid length against the registry format
+// of its country, e.g. letters where DE expects digits. Returns a failed
+// result naming the expected format when it doesn't match, nil otherwise.
+// IBANs of countries missing from the registry are not checked.
+func validateBbanFormat(countryCode string, iban string) *goiban.ValidationResult {
+	pattern, ok := bbanFormatPatterns[countryCode]
+
+	if !ok || len(iban) < 4 || pattern.MatchString(iban[4:]) {
+		return nil
+	}
+
+	message := fmt.Sprintf("Expected the BBAN format %v for %v", BBAN_FORMATS[countryCode], countryCode)
+	return goiban.NewValidationResult(false, message, iban)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestBbanFormatsMatchRegistryLengths(t *testing.T) {
+	part := regexp.MustCompile(`([0-9]+)!?[nac]`)
+
+	for countryCode, format := range BBAN_FORMATS {
+		length := 0
+		for _, match := range part.FindAllStringSubmatch(format, -1) {
+			n, _ := strconv.Atoi(match[1])
+			length += n
+		}
+
+		if expected, ok := IBAN_LENGTHS[countryCode]; !ok || length != expected-4 {
+			t.Errorf("expected the BBAN format %v of %v to have %d characters, got %d", format, countryCode, expected-4, length)
+		}
+	}
+}
+
+func TestBbanFormatPattern(t *testing.T) {
+	cases := map[string]string{
+		"8!n10!n": "^[0-9]{8}[0-9]{10}$",
+		"4!a3c":   "^[A-Z]{4}[A-Z0-9]{1,3}$",
+	}
+
+	for format, expected := range cases {
+		pattern, err := bbanFormatPattern(format)
+		if err != nil || pattern != expected {
+			t.Errorf("expected %v for %v, got %v (%v)", expected, format, pattern, err)
+		}
+	}
+
+	for _, format := range []string{"n", "8!x", "8!"} {
+		if _, err := bbanFormatPattern(format); err == nil {
+			t.Errorf("expected %q to be rejected", format)
+		}
+	}
+}
+
+func TestValidateBbanFormat(t *testing.T) {
+	for _, iban := range []string{"DE89370400440532013000", "GB29NWBK60161331926819", "FR1420041010050500013M02606", "NL91ABNA0417164300"} {
+		if result := validateBbanFormat(iban[:2], iban); result != nil {
+			t.Errorf("expected %v to match the format, got %v", iban, result.Messages)
+		}
+	}
+
+	for _, iban := range []string{"DE8937040044053201300A", "GB29NWBK6016133192681X", "NL9112340417164300"} {
+		if result := validateBbanFormat(iban[:2], iban); result == nil {
+			t.Errorf("expected %v not to match the format", iban)
+		}
+	}
+}
+
+func TestStructureMismatch(t *testing.T) {
+	strRes, err := validate(context.Background(), "DE8937040044053201300A", map[string]bool{})
+	if err != nil {
+		t.Errorf("failed to validate %v", err)
+		t.FailNow()
+	}
+
+	var result ValidationResponse
+	json.Unmarshal([]byte(strRes), &result)
+
+	if result.Valid || result.ErrorCode != ERROR_STRUCTURE_MISMATCH {
+		t.Errorf("expected a structure mismatch, got %v", strRes)
+	}
+
+	if len(result.Messages) != 1 || result.Messages[0] != "Expected the BBAN format 8!n10!n for DE" {
+		t.Errorf("expected the message to name the format, got %v", result.Messages)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+// bbanStructure holds the positions of the bank and branch code in the
+// BBAN of a country, taken from the SWIFT IBAN registry. The account number
+// is the rest of the BBAN following them, including national check digits.
+type bbanStructure struct {
+	bankStart, bankEnd     int
+	branchStart, branchEnd int
+}
+
+// BBAN_STRUCTURES maps country codes to the structure of their BBANs.
+// Countries without a separate branch code leave its positions at 0.
+var BBAN_STRUCTURES = map[string]bbanStructure{
+	"AD": {0, 4, 4, 8},
+	"AE": {0, 3, 0, 0},
+	"AT": {0, 5, 0, 0},
+	"BE": {0, 3, 0, 0},
+	"BG": {0, 4, 4, 8},
+	"CH": {0, 5, 0, 0},
+	"CY": {0, 3, 3, 8},
+	"CZ": {0, 4, 0, 0},
+	"DE": {0, 8, 0, 0},
+	"DK": {0, 4, 0, 0},
+	"EE": {0, 2, 0, 0},
+	"ES": {0, 4, 4, 8},
+	"FI": {0, 3, 0, 0},
+	"FR": {0, 5, 5, 10},
+	"GB": {0, 4, 4, 10},
+	"GI": {0, 4, 0, 0},
+	"GR": {0, 3, 3, 7},
+	"HR": {0, 7, 0, 0},
+	"HU": {0, 3, 3, 7},
+	"IE": {0, 4, 4, 10},
+	"IL": {0, 3, 3, 6},
+	"IT": {1, 6, 6, 11},
+	"LI": {0, 5, 0, 0},
+	"LT": {0, 5, 0, 0},
+	"LU": {0, 3, 0, 0},
+	"LV": {0, 4, 0, 0},
+	"MC": {0, 5, 5, 10},
+	"MT": {0, 4, 4, 9},
+	"NL": {0, 4, 0, 0},
+	"NO": {0, 4, 0, 0},
+	"PL": {0, 8, 0, 0},
+	"PT": {0, 4, 4, 8},
+	"RO": {0, 4, 0, 0},
+	"SA": {0, 2, 0, 0},
+	"SE": {0, 3, 0, 0},
+	"SI": {0, 5, 0, 0},
+	"SK": {0, 4, 0, 0},
+	"SM": {1, 6, 6, 11},
+}
+
+// BbanComponents are the parts of the BBAN of an IBAN, returned with
+// includeComponents=true
+type BbanComponents struct {
+	BankCode      string `json:"bankCode"`
+	BranchCode    string `json:"branchCode,omitempty"`
+	AccountNumber string `json:"accountNumber"`
+}
+
+// Returns the length of the account number in the BBAN of an IBAN of
+// valid length, including national check digits. Returns false for
+// countries with an unknown structure.
+func accountNumberLength(iban string) (int, bool) {
+	components := bbanComponents(iban)
+	if components == nil {
+		return 0, false
+	}
+
+	return len(components.AccountNumber), true
+}
+
+// Splits the BBAN of an IBAN of valid length into its components. Returns
+// nil for countries with an unknown structure.
+func bbanComponents(iban string) *BbanComponents {
+	if len(iban) < 4 {
+		return nil
+	}
+
+	structure, ok := BBAN_STRUCTURES[iban[:2]]
+	bban := iban[4:]
+	if !ok || len(bban) <= structure.bankEnd || len(bban) <= structure.branchEnd {
+		return nil
+	}
+
+	accountStart := structure.bankEnd
+	if structure.branchEnd > accountStart {
+		accountStart = structure.branchEnd
+	}
+
+	return &BbanComponents{
+		BankCode:      bban[structure.bankStart:structure.bankEnd],
+		BranchCode:    bban[structure.branchStart:structure.branchEnd],
+		AccountNumber: bban[accountStart:],
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestBbanComponents(t *testing.T) {
+	cases := map[string]*BbanComponents{
+		"DE89370400440532013000":           {BankCode: "37040044", AccountNumber: "0532013000"},
+		"FR1420041010050500013M02606":      {BankCode: "20041", BranchCode: "01005", AccountNumber: "0500013M02606"},
+		"IT60X0542811101000000123456":      {BankCode: "05428", BranchCode: "11101", AccountNumber: "000000123456"},
+		"GB29NWBK60161331926819":           {BankCode: "NWBK", BranchCode: "601613", AccountNumber: "31926819"},
+		"LC55HEMM000100010012001200023015": nil,
+	}
+
+	for iban, expected := range cases {
+		actual := bbanComponents(iban)
+
+		if (expected == nil) != (actual == nil) || (expected != nil && *expected != *actual) {
+			t.Errorf("unexpected components of %v: %+v", iban, actual)
+		}
+	}
+}
+
+func TestIncludeComponents(t *testing.T) {
+	strRes, err := validate(context.Background(), "DE89370400440532013000", map[string]bool{"includeComponents": true})
+	if err != nil {
+		t.Errorf("failed to validate %v", err)
+		t.FailNow()
+	}
+
+	var result ValidationResponse
+	json.Unmarshal([]byte(strRes), &result)
+
+	if result.Components == nil || result.Components.BankCode != "37040044" || result.Components.AccountNumber != "0532013000" {
+		t.Errorf("expected the components of the IBAN, got %v", strRes)
+	}
+
+	strRes, err = validate(context.Background(), "DE89370400440532013000", map[string]bool{})
+	if err != nil {
+		t.Errorf("failed to validate %v", err)
+		t.FailNow()
+	}
+
+	result = ValidationResponse{}
+	json.Unmarshal([]byte(strRes), &result)
+
+	if result.Components != nil {
+		t.Errorf("expected no components without includeComponents, got %v", strRes)
+	}
+}
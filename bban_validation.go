@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// Processes requests to the /validate-bban/ url. The IBAN is constructed
+// by computing the check digits of the BBAN for the country and validated
+// like the input of /validate. Only countries in IBAN_LENGTHS are accepted.
+func bbanValidationHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Allow CORS
+	allowOrigin(w, r)
+
+	input := ps.ByName("bban")
+	if exceedsMaxLength(input) {
+		writeTooLong(w, r)
+		return
+	}
+
+	countryCode := strings.ToUpper(ps.ByName("countryCode"))
+	if _, ok := IBAN_LENGTHS[countryCode]; !ok {
+		writeError(w, r, http.StatusBadRequest, ERROR_UNKNOWN_COUNTRY, "Unknown country code.")
+		return
+	}
+
+	bban := normalizeIban(input)
+	if len(bban) == 0 {
+		res, _ := json.MarshalIndent(errorResult(ERROR_EMPTY_INPUT, "Empty request.", input), "", "  ")
+		writeResponse(w, r, http.StatusBadRequest, res)
+		return
+	}
+
+	iban, ok := ibanFromBban(countryCode, bban)
+	if !ok {
+		res, _ := json.MarshalIndent(errorResult(ERROR_NOT_PARSEABLE, "Cannot parse as IBAN: invalid characters in the BBAN", input), "", "  ")
+		writeResponse(w, r, http.StatusBadRequest, res)
+		return
+	}
+
+	writeValidation(w, r, iban, validationConfig(r), "", 0)
+}
+
+// Constructs the IBAN of a BBAN by computing the check digits for the
+// country. Reports false when the BBAN contains characters other than
+// A-Z and 0-9.
+func ibanFromBban(countryCode string, bban string) (string, bool) {
+	digits, ok := checkDigits(countryCode, bban)
+	if !ok {
+		return "", false
+	}
+
+	return countryCode + digits + bban, true
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestIbanFromBban(t *testing.T) {
+	cases := map[string]string{
+		"370400440532013000": "DE89370400440532013000",
+		"WEST12345698765432": "GB82WEST12345698765432",
+	}
+
+	for bban, expected := range cases {
+		if iban, ok := ibanFromBban(expected[:2], bban); !ok || iban != expected {
+			t.Errorf("expected %v for %v, got %v", expected, bban, iban)
+		}
+	}
+
+	if _, ok := ibanFromBban("DE", "3704-0044"); ok {
+		t.Errorf("expected invalid characters to be rejected")
+	}
+}
+
+func TestValidateBban(t *testing.T) {
+	resp, err := http.Get(server.URL + "/validate-bban/de/370400440532013000")
+	if err != nil {
+		t.Errorf("failed to validate the BBAN %v", err)
+		t.FailNow()
+	}
+	defer resp.Body.Close()
+
+	var result ValidationResponse
+	json.NewDecoder(resp.Body).Decode(&result)
+
+	if !result.Valid || result.Iban != "DE89370400440532013000" {
+		t.Errorf("expected the computed IBAN to be valid, got %v", result.ValidationResult)
+	}
+}
+
+func TestValidateBbanUnknownCountry(t *testing.T) {
+	resp, err := http.Get(server.URL + "/validate-bban/XX/370400440532013000")
+	if err != nil {
+		t.Errorf("failed to validate the BBAN %v", err)
+		t.FailNow()
+	}
+	defer resp.Body.Close()
+
+	var result ErrorResponse
+	json.NewDecoder(resp.Body).Decode(&result)
+
+	if resp.StatusCode != http.StatusBadRequest || result.ErrorCode != ERROR_UNKNOWN_COUNTRY {
+		t.Errorf("expected the country to be rejected, got %v %v", resp.StatusCode, result.ErrorCode)
+	}
+}
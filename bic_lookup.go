@@ -0,0 +1,90 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// SELECT_BANK_BY_BIC finds a bank by its BIC, exact matches are preferred
+// over the alternative spelling with or without the XXX branch code.
+const SELECT_BANK_BY_BIC = "SELECT bic, bankcode, name, country FROM BANK_DATA WHERE bic = ? OR bic = ? ORDER BY bic = ? DESC LIMIT 1"
+
+var bicPattern = regexp.MustCompile(`^[A-Z]{6}[A-Z0-9]{2}([A-Z0-9]{3})?$`)
+
+// BicResult is the bank data belonging to a BIC
+type BicResult struct {
+	Bic         string `json:"bic"`
+	BankCode    string `json:"bankCode"`
+	Name        string `json:"name"`
+	CountryCode string `json:"countryCode"`
+}
+
+// Processes requests to the /bic/ url
+func bicLookupHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Allow CORS
+	allowOrigin(w, r)
+
+	bic := strings.ToUpper(ps.ByName("bic"))
+	if !bicPattern.MatchString(bic) {
+		writeError(w, r, http.StatusBadRequest, ERROR_INVALID_BIC, "Expected a BIC with 8 or 11 characters.")
+		return
+	}
+
+	result, err := lookupBic(bic)
+	if err == sql.ErrNoRows {
+		writeError(w, r, http.StatusNotFound, ERROR_BIC_NOT_FOUND, "Unknown BIC.")
+		return
+	}
+
+	if err != nil {
+		log.Printf("Error while looking up BIC %v: %v", bic, err)
+		writeLookupError(w, r, err)
+		return
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, data)
+}
+
+// Looks up the bank with the 8 or 11 character BIC. The branch code is
+// optional, COBADEFF and COBADEFFXXX both find the same bank.
+func lookupBic(bic string) (*BicResult, error) {
+	alternative := bic + "XXX"
+	if len(bic) == 11 && strings.HasSuffix(bic, "XXX") {
+		alternative = bic[:8]
+	}
+
+	var result BicResult
+	err := withDBRetry(func() error {
+		return db.QueryRow(SELECT_BANK_BY_BIC, bic, alternative, bic).Scan(
+			&result.Bic, &result.BankCode, &result.Name, &result.CountryCode)
+	})
+	if err != nil {
+		return nil, schemaError(err)
+	}
+
+	return &result, nil
+}
+
+// Writes an ErrorResponse with message
+func writeError(w http.ResponseWriter, r *http.Request, status int, code string, message string) {
+	data, _ := json.MarshalIndent(ErrorResponse{false, code, message}, "", "  ")
+	writeResponse(w, r, status, data)
+}
+
+// Writes the ErrorResponse of a failed bank data lookup
+func writeLookupError(w http.ResponseWriter, r *http.Request, err error) {
+	result, status := lookupError(err, "")
+	writeError(w, r, status, result.ErrorCode, result.Messages[0])
+}
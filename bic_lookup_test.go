@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestBicLookupRejectsMalformedBic(t *testing.T) {
+	for _, bic := range []string{"COBADE", "COBADEFF3", "COBA-EFFXXX"} {
+		resp, err := http.Get(server.URL + "/bic/" + bic)
+
+		if err != nil {
+			t.Errorf("failed to look up bic %v", err)
+			t.FailNow()
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected status %v for %v, got %v", http.StatusBadRequest, bic, resp.StatusCode)
+		}
+	}
+}
+
+func TestBicLookup(t *testing.T) {
+	if db.Ping() != nil {
+		t.Skip("database is not available")
+	}
+
+	for _, bic := range []string{"COBADEFF", "COBADEFFXXX"} {
+		result, err := lookupBic(bic)
+
+		if err != nil {
+			t.Errorf("failed to look up %v: %v", bic, err)
+			continue
+		}
+
+		if result.CountryCode != "DE" || result.Name == "" {
+			t.Errorf("unexpected bank data for %v: %v", bic, result)
+		}
+	}
+}
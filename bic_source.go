@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/fourcube/goiban"
+)
+
+// SELECT_BIC_SOURCE reads the optional source of the BIC of a bank, e.g.
+// "registry" for BICs from the central bank or "derived" for BICs which
+// were derived from other data. Not every dataset contains the column.
+const SELECT_BIC_SOURCE = "SELECT bic_source FROM BANK_DATA WHERE bankcode = ? AND country = ? LIMIT 1"
+
+// bicSourceColumn tells whether the dataset contains the bic_source column
+var bicSourceColumn = &optionalColumns{probe: "SELECT bic_source FROM BANK_DATA LIMIT 1"}
+
+// Looks up the source of the BIC found for result. Returns "" when no BIC
+// was found or the dataset has no source for it. While the circuit breaker
+// is open the lookup fails right away.
+func bicSource(ctx context.Context, countryCode string, result *goiban.ValidationResult) (string, error) {
+	if result.BankData.BankCode == "" || result.BankData.Bic == "" || bicSourceColumn.missing() {
+		return "", nil
+	}
+
+	err := dbBreaker.allow()
+	if err != nil {
+		return "", err
+	}
+
+	source, err := lookupBicSource(ctx, countryCode, result)
+	dbBreaker.record(err)
+
+	return source, err
+}
+
+func lookupBicSource(ctx context.Context, countryCode string, result *goiban.ValidationResult) (string, error) {
+	slot, err := acquireDBSlot()
+	if err != nil {
+		return "", err
+	}
+	defer slot.release()
+
+	exist, err := bicSourceColumn.exist(ctx)
+	if err != nil || !exist {
+		return "", err
+	}
+
+	var source sql.NullString
+	err = withDBRetry(func() error {
+		ctx, cancel := lookupContext(ctx)
+		defer cancel()
+
+		return db.QueryRowContext(ctx, SELECT_BIC_SOURCE, result.BankData.BankCode, countryCode).Scan(&source)
+	})
+
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if isUndefinedColumnError(err) {
+		bicSourceColumn.setMissing()
+		return "", nil
+	}
+	if err == context.DeadlineExceeded {
+		return "", errDBTimeout
+	}
+	if err != nil {
+		return "", schemaError(err)
+	}
+
+	return strings.TrimSpace(source.String), nil
+}
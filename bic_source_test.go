@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fourcube/goiban"
+)
+
+func TestBicSourceWithoutBic(t *testing.T) {
+	result := goiban.NewValidationResult(true, "", "DE89370400440532013000")
+	result.BankData.BankCode = "37040044"
+
+	// nothing is looked up without a BIC
+	source, err := bicSource(context.Background(), "DE", result)
+	if err != nil || source != "" {
+		t.Errorf("expected no source, got %q %v", source, err)
+	}
+}
+
+func TestBicSource(t *testing.T) {
+	if db.Ping() != nil {
+		t.Skip("database is not available")
+	}
+
+	result := goiban.NewValidationResult(true, "", "DE89370400440532013000")
+	result.BankData.BankCode = "37040044"
+	result.BankData.Bic = "COBADEFFXXX"
+
+	// datasets without the column are not an error
+	if _, err := bicSource(context.Background(), "DE", result); err != nil {
+		t.Errorf("failed to look up the BIC source %v", err)
+	}
+}
+
+func TestBicSourceColumnIsProbedOnce(t *testing.T) {
+	defer func(cb *circuitBreaker) {
+		dbBreaker = cb
+		atomic.StoreInt32(&bicSourceColumn.state, COLUMNS_UNKNOWN)
+	}(dbBreaker)
+
+	dbBreaker = newCircuitBreaker(1, time.Hour)
+	dbBreaker.allow()
+	dbBreaker.record(errDBTimeout)
+
+	result := goiban.NewValidationResult(true, "", "DE89370400440532013000")
+	result.BankData.BankCode = "37040044"
+	result.BankData.Bic = "COBADEFFXXX"
+
+	// the lookup goes through the circuit breaker
+	if _, err := bicSource(context.Background(), "DE", result); err != errCircuitOpen {
+		t.Errorf("expected the open breaker to fail the lookup, got %v", err)
+	}
+
+	// datasets known to lack the column aren't queried at all
+	bicSourceColumn.setMissing()
+	if source, err := bicSource(context.Background(), "DE", result); err != nil || source != "" {
+		t.Errorf("expected no source without the column, got %q %v", source, err)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Limits the size of request bodies to maxBytes, 0 disables the limit.
+// Requests announcing a larger body are rejected with HTTP 413 right away.
+// Reading more than maxBytes of a body without a Content-Length fails with
+// an error for which bodyTooLarge reports true, the handlers answer it
+// with HTTP 413 as well.
+func bodyLimitHandler(maxBytes int64, next http.Handler) http.Handler {
+	if maxBytes <= 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > maxBytes {
+			writeBodyTooLarge(w, r)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Reports whether reading the request body failed because it exceeds the
+// limit of bodyLimitHandler
+func bodyTooLarge(err error) bool {
+	var maxBytesError *http.MaxBytesError
+	return errors.As(err, &maxBytesError)
+}
+
+func writeBodyTooLarge(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusRequestEntityTooLarge, ERROR_BODY_TOO_LARGE, fmt.Sprintf("Request body exceeds the maximum size of %d bytes.", cfg.MaxBodySize))
+}
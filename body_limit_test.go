@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestBodyLimit(t *testing.T) {
+	router := httprouter.New()
+	router.POST("/validate", postValidationHandler)
+	router.POST("/validate/batch", batchValidationHandler)
+
+	limited := httptest.NewServer(bodyLimitHandler(64, router))
+	defer limited.Close()
+
+	body := `{"iban": "DE89370400440532013000", "getBIC": false, "validateBankCode": false, "suggest": false}`
+
+	cases := map[string]io.Reader{
+		// the announced length is rejected before the body is read
+		"/validate": strings.NewReader(body),
+		// without a length the limit is hit while the body is decoded
+		"/validate/batch": io.MultiReader(strings.NewReader(`["` + strings.Repeat("DE89370400440532013000", 5) + `"]`)),
+	}
+
+	for path, reader := range cases {
+		resp, err := http.Post(limited.URL+path, "application/json", reader)
+		if err != nil {
+			t.Errorf("failed to post %v", err)
+			t.FailNow()
+		}
+
+		var result ErrorResponse
+		json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusRequestEntityTooLarge {
+			t.Errorf("expected HTTP 413 for %v, got %v", path, resp.StatusCode)
+		}
+
+		if result.ErrorCode != ERROR_BODY_TOO_LARGE {
+			t.Errorf("expected error code %v for %v, got %v", ERROR_BODY_TOO_LARGE, path, result.ErrorCode)
+		}
+	}
+
+	// bodies within the limit are passed on
+	resp, err := http.Post(limited.URL+"/validate", "application/json", strings.NewReader(`{"iban": "D"}`))
+	if err != nil {
+		t.Errorf("failed to post %v", err)
+		t.FailNow()
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected a small body to be accepted, got %v", resp.StatusCode)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/pmylund/go-cache"
+)
+
+// REDIS_KEY_PREFIX is prepended to all keys stored in Redis
+const REDIS_KEY_PREFIX = "goiban:"
+
+const (
+	// REDIS_TIMEOUT bounds connecting to Redis and every read and write, so
+	// an unreachable Redis doesn't hold up the validations
+	REDIS_TIMEOUT = 250 * time.Millisecond
+
+	// REDIS_RETRY_INTERVAL is the time between the pings of an unavailable
+	// Redis
+	REDIS_RETRY_INTERVAL = 5 * time.Second
+)
+
+// Cache stores rendered validation results
+type Cache interface {
+	Get(key string) (string, bool)
+	Set(key string, value string, ttl time.Duration)
+	Delete(key string)
+}
+
+// Creates the cache selected by config. A Redis backed cache is used when
+// a Redis url is configured, otherwise results are cached in memory. The
+// in-memory cache holds at most CacheMaxEntries results, it is unbounded
+// when the limit is 0.
+func newCache(config *Config) Cache {
+	var memory Cache = newMemoryCache(config.CacheTTL.Duration, config.CacheCleanup.Duration)
+	if config.CacheMaxEntries > 0 {
+		memory = newLRUCache(config.CacheTTL.Duration, config.CacheCleanup.Duration, config.CacheMaxEntries)
+	}
+
+	if config.RedisURL == "" {
+		return memory
+	}
+
+	options, err := redis.ParseURL(config.RedisURL)
+	if err != nil {
+		log.Fatalf("Invalid Redis url: %v", err)
+	}
+
+	return newRedisCache(redis.NewClient(redisOptions(options)), memory)
+}
+
+// Sets the short timeouts of REDIS_TIMEOUT on options
+func redisOptions(options *redis.Options) *redis.Options {
+	options.DialTimeout = REDIS_TIMEOUT
+	options.ReadTimeout = REDIS_TIMEOUT
+	options.WriteTimeout = REDIS_TIMEOUT
+	return options
+}
+
+// memoryCache keeps the results in process
+type memoryCache struct {
+	*cache.Cache
+}
+
+func newMemoryCache(ttl time.Duration, cleanup time.Duration) *memoryCache {
+	return &memoryCache{cache.New(ttl, cleanup)}
+}
+
+func (mc *memoryCache) Get(key string) (string, bool) {
+	val, ok := mc.Cache.Get(key)
+	if !ok {
+		return "", false
+	}
+
+	value, ok := val.(string)
+	return value, ok
+}
+
+func (mc *memoryCache) Set(key string, value string, ttl time.Duration) {
+	mc.Cache.Set(key, value, ttl)
+}
+
+// redisCache shares the results between all instances of the service. When
+// Redis can't be reached the fallback cache is used instead. After a failed
+// call Redis is left alone until a ping in the background succeeds, so the
+// validations don't wait for the timeouts of every call.
+type redisCache struct {
+	client   *redis.Client
+	fallback Cache
+
+	// down is 1 while Redis is unavailable
+	down int32
+}
+
+// Creates the cache, Redis is pinged once to find out whether it is available
+func newRedisCache(client *redis.Client, fallback Cache) *redisCache {
+	rc := &redisCache{client: client, fallback: fallback}
+
+	err := client.Ping().Err()
+	if err != nil {
+		rc.markDown(err)
+	}
+
+	return rc
+}
+
+func (rc *redisCache) Get(key string) (string, bool) {
+	if rc.unavailable() {
+		return rc.fallback.Get(key)
+	}
+
+	value, err := rc.client.Get(REDIS_KEY_PREFIX + key).Result()
+	if err == redis.Nil {
+		return "", false
+	}
+
+	if err != nil {
+		rc.markDown(err)
+		return rc.fallback.Get(key)
+	}
+
+	return value, true
+}
+
+func (rc *redisCache) Set(key string, value string, ttl time.Duration) {
+	if rc.unavailable() {
+		rc.fallback.Set(key, value, ttl)
+		return
+	}
+
+	err := rc.client.Set(REDIS_KEY_PREFIX+key, value, ttl).Err()
+	if err != nil {
+		rc.markDown(err)
+		rc.fallback.Set(key, value, ttl)
+	}
+}
+
+func (rc *redisCache) Delete(key string) {
+	if !rc.unavailable() {
+		rc.client.Del(REDIS_KEY_PREFIX + key)
+	}
+	rc.fallback.Delete(key)
+}
+
+func (rc *redisCache) unavailable() bool {
+	return atomic.LoadInt32(&rc.down) == 1
+}
+
+// Switches to the fallback cache and pings Redis every REDIS_RETRY_INTERVAL
+// until it is available again
+func (rc *redisCache) markDown(err error) {
+	if !atomic.CompareAndSwapInt32(&rc.down, 0, 1) {
+		return
+	}
+
+	log.Printf("Redis is unavailable, using the in-memory cache until it can be reached: %v", err)
+
+	go func() {
+		for range time.Tick(REDIS_RETRY_INTERVAL) {
+			if rc.client.Ping().Err() == nil {
+				log.Printf("Redis is available again")
+				atomic.StoreInt32(&rc.down, 0)
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+func TestMemoryCache(t *testing.T) {
+	mc := newMemoryCache(time.Minute, time.Minute)
+	mc.Set("key", "value", 0)
+
+	value, found := mc.Get("key")
+	if !found || value != "value" {
+		t.Errorf("expected cached value, got %v", value)
+	}
+
+	mc.Delete("key")
+	if _, found := mc.Get("key"); found {
+		t.Errorf("expected value to be deleted")
+	}
+}
+
+func TestRedisCacheFallsBackWhenUnavailable(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1"})
+	rc := newRedisCache(client, newMemoryCache(time.Minute, time.Minute))
+
+	rc.Set("key", "value", time.Minute)
+
+	value, found := rc.Get("key")
+	if !found || value != "value" {
+		t.Errorf("expected value from the fallback cache, got %v", value)
+	}
+}
+
+func TestUnreachableRedisIsSkipped(t *testing.T) {
+	// a blackholed address only fails after the timeouts
+	client := redis.NewClient(redisOptions(&redis.Options{Addr: "10.255.255.1:6379"}))
+	rc := newRedisCache(client, newMemoryCache(time.Minute, time.Minute))
+
+	if !rc.unavailable() {
+		t.Errorf("expected Redis to be marked as unavailable")
+	}
+
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		rc.Set("key", "value", time.Minute)
+		rc.Get("key")
+	}
+
+	if elapsed := time.Since(start); elapsed > REDIS_TIMEOUT {
+		t.Errorf("expected the fallback cache to be used right away, took %v", elapsed)
+	}
+}
+
+func TestLRUCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	lc := newLRUCache(time.Minute, 0, 2)
+	lc.Set("a", "1", 0)
+	lc.Set("b", "2", 0)
+
+	// a becomes the most recently used entry
+	lc.Get("a")
+	lc.Set("c", "3", 0)
+
+	if _, found := lc.Get("b"); found {
+		t.Errorf("expected the least recently used entry to be evicted")
+	}
+
+	for _, key := range []string{"a", "c"} {
+		if _, found := lc.Get(key); !found {
+			t.Errorf("expected %v to be cached", key)
+		}
+	}
+
+	if lc.Len() != 2 {
+		t.Errorf("expected 2 entries, got %v", lc.Len())
+	}
+}
+
+func TestLRUCacheExpiresEntries(t *testing.T) {
+	lc := newLRUCache(time.Minute, 0, 10)
+	lc.Set("key", "value", time.Millisecond)
+	lc.Set("other", "value", 0)
+
+	time.Sleep(5 * time.Millisecond)
+
+	if _, found := lc.Get("key"); found {
+		t.Errorf("expected the entry to expire")
+	}
+
+	lc.deleteExpired()
+	if _, found := lc.Get("other"); !found || lc.Len() != 1 {
+		t.Errorf("expected only the unexpired entry to be kept")
+	}
+}
+
+func TestCacheKeyIsHashed(t *testing.T) {
+	defer func(hash bool) { cfg.CacheKeyHash = hash }(cfg.CacheKeyHash)
+	cfg.CacheKeyHash = true
+
+	long := "DE89370400440532013000" + strings.Repeat("0", 1000)
+	inputs := []struct {
+		iban   string
+		config map[string]bool
+	}{
+		{"DE89370400440532013000", map[string]bool{}},
+		{"DE89370400440532013000", map[string]bool{"getBIC": true}},
+		{"DE89370400440532013000", map[string]bool{"validateBankCode": true}},
+		{"DE89370400440532013001", map[string]bool{}},
+		{long, map[string]bool{}},
+	}
+
+	keys := map[string]bool{}
+	for _, input := range inputs {
+		key := cacheKey(input.iban, input.config)
+		if len(key) != 64 {
+			t.Errorf("expected a key of 64 characters, got %v", key)
+		}
+
+		if keys[key] {
+			t.Errorf("expected distinct keys, got %v twice", key)
+		}
+		keys[key] = true
+	}
+
+	cfg.CacheKeyHash = false
+	if key := cacheKey("DE89370400440532013000", map[string]bool{}); !strings.HasPrefix(key, "DE89370400440532013000") {
+		t.Errorf("expected the plain key without hashing, got %v", key)
+	}
+}
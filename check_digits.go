@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// CheckDigitsResult is the response of /checkdigits
+type CheckDigitsResult struct {
+	CountryCode string `json:"countryCode"`
+	Bban        string `json:"bban"`
+	CheckDigits string `json:"checkDigits"`
+}
+
+// Processes requests to the /checkdigits url. Returns the ISO 7064 mod 97-10
+// check digits of the IBAN of the BBAN in the country. Only countries in
+// IBAN_LENGTHS are accepted, the length of the BBAN isn't checked.
+func checkDigitsHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Allow CORS
+	allowOrigin(w, r)
+
+	input := ps.ByName("bban")
+	if exceedsMaxLength(input) {
+		writeTooLong(w, r)
+		return
+	}
+
+	countryCode := strings.ToUpper(ps.ByName("countryCode"))
+	if _, ok := IBAN_LENGTHS[countryCode]; !ok {
+		writeError(w, r, http.StatusBadRequest, ERROR_UNKNOWN_COUNTRY, "Unknown country code.")
+		return
+	}
+
+	bban := normalizeIban(input)
+	if len(bban) == 0 {
+		writeError(w, r, http.StatusBadRequest, ERROR_EMPTY_INPUT, "Empty request.")
+		return
+	}
+
+	if message := invalidCharacters(bban); message != "" {
+		writeError(w, r, http.StatusBadRequest, ERROR_NOT_PARSEABLE, message)
+		return
+	}
+
+	digits, _ := checkDigits(countryCode, bban)
+
+	data, err := json.MarshalIndent(CheckDigitsResult{countryCode, bban, digits}, "", "  ")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, data)
+}
+
+// Computes the two check digits of the IBAN of a BBAN in the country.
+// Reports false when the BBAN contains characters other than A-Z and 0-9.
+func checkDigits(countryCode string, bban string) (string, bool) {
+	remainder := mod97(bban + countryCode + "00")
+	if remainder < 0 {
+		return "", false
+	}
+
+	return fmt.Sprintf("%02d", 98-remainder), true
+}
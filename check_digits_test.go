@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestCheckDigits(t *testing.T) {
+	resp, err := http.Get(server.URL + "/checkdigits/gb/WEST12345698765432")
+	if err != nil {
+		t.Errorf("failed to compute the check digits %v", err)
+		t.FailNow()
+	}
+	defer resp.Body.Close()
+
+	var result CheckDigitsResult
+	json.NewDecoder(resp.Body).Decode(&result)
+
+	if result.CountryCode != "GB" || result.CheckDigits != "82" {
+		t.Errorf("expected the check digits 82, got %v", result)
+	}
+}
+
+func TestCheckDigitsInvalidInput(t *testing.T) {
+	cases := map[string]string{
+		"/checkdigits/XX/370400440532013000": ERROR_UNKNOWN_COUNTRY,
+		"/checkdigits/DE/3704-0044.0532":     ERROR_NOT_PARSEABLE,
+	}
+
+	for url, code := range cases {
+		resp, err := http.Get(server.URL + url)
+		if err != nil {
+			t.Errorf("failed to compute the check digits %v", err)
+			t.FailNow()
+		}
+
+		var result ErrorResponse
+		json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest || result.ErrorCode != code {
+			t.Errorf("expected %v for %v, got %v %v", code, url, resp.StatusCode, result)
+		}
+	}
+}
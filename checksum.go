@@ -0,0 +1,32 @@
+package main
+
+// Calculates the ISO 7064 mod 97-10 remainder of iban. The IBAN is
+// rearranged (country code and check digits moved to the end) and letters
+// are replaced by numbers (A = 10, ..., Z = 35). A valid IBAN yields 1.
+// Returns -1 when iban contains characters other than A-Z and 0-9.
+func ibanChecksum(iban string) int {
+	if len(iban) < 4 {
+		return -1
+	}
+
+	return mod97(iban[4:] + iban[:4])
+}
+
+// Calculates the remainder of the digits of value divided by 97, letters
+// count as two digit numbers (A = 10, ..., Z = 35).
+func mod97(value string) int {
+	remainder := 0
+
+	for _, r := range value {
+		switch {
+		case r >= '0' && r <= '9':
+			remainder = (remainder*10 + int(r-'0')) % 97
+		case r >= 'A' && r <= 'Z':
+			remainder = (remainder*100 + int(r-'A') + 10) % 97
+		default:
+			return -1
+		}
+	}
+
+	return remainder
+}
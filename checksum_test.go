@@ -0,0 +1,18 @@
+package main
+
+import "testing"
+
+func TestIbanChecksum(t *testing.T) {
+	cases := map[string]int{
+		"DE89370400440532013000": 1,
+		"DE89370400440532013001": 28,
+		"GB82WEST12345698765432": 1,
+		"DE89-3704":              -1,
+	}
+
+	for iban, expected := range cases {
+		if checksum := ibanChecksum(iban); checksum != expected {
+			t.Errorf("expected checksum %v for %v, got %v", expected, iban, checksum)
+		}
+	}
+}
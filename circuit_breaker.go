@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// States of the circuit breaker, recorded as the db.breaker gauge
+const (
+	BREAKER_CLOSED    = 0
+	BREAKER_HALF_OPEN = 1
+	BREAKER_OPEN      = 2
+)
+
+// errCircuitOpen is returned instead of a lookup while the database is
+// considered down. It is reported like an unavailable database.
+var errCircuitOpen = errors.New("database circuit breaker is open")
+
+// circuitBreaker fails the bank data lookups fast once threshold lookups
+// in a row have failed. After the cooldown a single lookup is let through
+// to probe the database, its success closes the breaker again, a failure
+// opens it for another cooldown.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	state     int
+	failures  int
+	openedAt  time.Time
+	probing   bool
+}
+
+// dbBreaker guards the bank data lookups, nil when it is disabled
+var dbBreaker *circuitBreaker
+
+// Creates a breaker which opens after threshold failures in a row, 0
+// disables it
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	if threshold <= 0 {
+		return nil
+	}
+
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+// Returns errCircuitOpen when the lookup must not be made. Every allowed
+// lookup has to be followed by a call of record.
+func (cb *circuitBreaker) allow() error {
+	if cb == nil {
+		return nil
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case BREAKER_OPEN:
+		if time.Since(cb.openedAt) < cb.cooldown {
+			return errCircuitOpen
+		}
+
+		cb.setState(BREAKER_HALF_OPEN)
+		log.Printf("Database circuit breaker half-open, probing the database")
+		fallthrough
+	case BREAKER_HALF_OPEN:
+		// only one probe at a time
+		if cb.probing {
+			return errCircuitOpen
+		}
+		cb.probing = true
+	}
+
+	return nil
+}
+
+// Records the outcome of an allowed lookup. Errors which are no sign of
+// an unavailable database, like a busy lookup queue or a missing table,
+// neither count as failure nor as success.
+func (cb *circuitBreaker) record(err error) {
+	if cb == nil {
+		return
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.probing = false
+
+	switch {
+	case err == errDBBusy || err == errDBSchema || err == context.Canceled:
+		return
+	case err == nil:
+		cb.failures = 0
+		if cb.state != BREAKER_CLOSED {
+			cb.setState(BREAKER_CLOSED)
+			log.Printf("Database circuit breaker closed, the database is available again")
+		}
+		return
+	}
+
+	cb.failures++
+	if cb.state == BREAKER_HALF_OPEN || cb.failures >= cb.threshold {
+		cb.openedAt = time.Now()
+		if cb.state != BREAKER_OPEN {
+			log.Printf("Database circuit breaker opened after %d failed lookups, failing lookups for %v: %v", cb.failures, cb.cooldown, err)
+		}
+		cb.setState(BREAKER_OPEN)
+	}
+}
+
+// setState changes the state, the caller holds the lock
+func (cb *circuitBreaker) setState(state int) {
+	cb.state = state
+	registerBreakerState(state)
+}
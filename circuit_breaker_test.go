@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	cb := newCircuitBreaker(3, time.Hour)
+	failure := errors.New("connection refused")
+
+	for i := 0; i < 3; i++ {
+		if err := cb.allow(); err != nil {
+			t.Fatalf("expected lookup %v to be allowed, got %v", i, err)
+		}
+		cb.record(failure)
+	}
+
+	if err := cb.allow(); err != errCircuitOpen {
+		t.Errorf("expected the breaker to be open, got %v", err)
+	}
+
+	result, status := lookupError(errCircuitOpen, "DE89370400440532013000")
+	if result.ErrorCode != ERROR_DB_UNAVAILABLE || status != http.StatusServiceUnavailable {
+		t.Errorf("expected an open breaker to be reported as DB_UNAVAILABLE, got %v %v", result.ErrorCode, status)
+	}
+}
+
+func TestCircuitBreakerIgnoresBusyAndSuccess(t *testing.T) {
+	cb := newCircuitBreaker(2, time.Hour)
+	failure := errors.New("connection refused")
+
+	// a success in between resets the count, a busy queue doesn't count
+	for _, err := range []error{failure, nil, failure, errDBBusy, errDBSchema} {
+		cb.allow()
+		cb.record(err)
+	}
+
+	if err := cb.allow(); err != nil {
+		t.Errorf("expected the breaker to stay closed, got %v", err)
+	}
+}
+
+func TestCircuitBreakerHalfOpens(t *testing.T) {
+	cb := newCircuitBreaker(1, 10*time.Millisecond)
+	failure := errors.New("connection refused")
+
+	cb.allow()
+	cb.record(failure)
+
+	time.Sleep(20 * time.Millisecond)
+
+	// a single probe after the cooldown
+	if err := cb.allow(); err != nil {
+		t.Fatalf("expected a probe after the cooldown, got %v", err)
+	}
+	if err := cb.allow(); err != errCircuitOpen {
+		t.Errorf("expected a single probe at a time, got %v", err)
+	}
+
+	// a failed probe opens the breaker again
+	cb.record(failure)
+	if err := cb.allow(); err != errCircuitOpen {
+		t.Errorf("expected a failed probe to open the breaker, got %v", err)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	cb.allow()
+	cb.record(nil)
+
+	if err := cb.allow(); err != nil || cb.state != BREAKER_CLOSED {
+		t.Errorf("expected a successful probe to close the breaker, got %v", err)
+	}
+}
+
+func TestDisabledCircuitBreaker(t *testing.T) {
+	cb := newCircuitBreaker(0, time.Hour)
+	cb.record(errors.New("connection refused"))
+
+	if err := cb.allow(); err != nil {
+		t.Errorf("expected a disabled breaker to allow every lookup, got %v", err)
+	}
+}
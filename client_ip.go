@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// trustedProxies are the networks of the proxies whose X-Forwarded-For and
+// X-Real-IP headers are honored, set from cfg.TrustedProxies
+var trustedProxies []*net.IPNet
+
+// Returns the IP address of the client which sent r. The forwarded headers
+// are only honored for connections of trusted proxies (and Unix sockets,
+// whose peers are local), otherwise anyone could claim any address. Every
+// proxy appends the address it received the request from to
+// X-Forwarded-For, so the right-most address which is not a trusted proxy
+// is the client. X-Real-IP and the remote address of the connection are
+// used when no address was forwarded. Both IPv4 and IPv6 addresses, with
+// or without port, are understood.
+func clientIP(r *http.Request) string {
+	remote := parseIP(r.RemoteAddr)
+	if remote != nil && !isTrustedProxy(remote) {
+		return remote.String()
+	}
+
+	var forwarded []net.IP
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		for _, entry := range strings.Split(header, ",") {
+			if ip := parseIP(entry); ip != nil {
+				forwarded = append(forwarded, ip)
+			}
+		}
+	}
+
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		if !isTrustedProxy(forwarded[i]) {
+			return forwarded[i].String()
+		}
+	}
+
+	// the request passed trusted proxies only
+	if len(forwarded) > 0 {
+		return forwarded[0].String()
+	}
+
+	if ip := parseIP(r.Header.Get("X-Real-IP")); ip != nil {
+		return ip.String()
+	}
+
+	if remote != nil {
+		return remote.String()
+	}
+
+	return r.RemoteAddr
+}
+
+// Parses an IP address which may carry a port, e.g. "192.0.2.1:8080" or
+// "[2001:db8::1]:443". Returns nil when address is not an IP address.
+func parseIP(address string) net.IP {
+	address = strings.TrimSpace(address)
+
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		address = host
+	}
+
+	return net.ParseIP(strings.Trim(address, "[]"))
+}
+
+// Parses the trusted proxies, given as CIDR ranges (e.g. "10.0.0.0/8") or
+// single addresses
+func parseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid address %q", proxy)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR range %q", proxy)
+		}
+
+		networks = append(networks, network)
+	}
+
+	return networks, nil
+}
+
+// Reports whether ip belongs to one of the trusted proxies
+func isTrustedProxy(ip net.IP) bool {
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	defer func(proxies []*net.IPNet) { trustedProxies = proxies }(trustedProxies)
+	trustedProxies, _ = parseTrustedProxies([]string{"10.0.0.0/8", "192.168.0.0/16"})
+
+	cases := []struct {
+		remoteAddr string
+		forwarded  string
+		realIP     string
+		expected   string
+	}{
+		{"192.0.2.1:1234", "", "", "192.0.2.1"},
+		{"[2001:db8::1]:1234", "", "", "2001:db8::1"},
+		{"10.0.0.1:1234", "", "198.51.100.7", "198.51.100.7"},
+		{"10.0.0.1:1234", "203.0.113.5, 10.0.0.2", "", "203.0.113.5"},
+		{"10.0.0.1:1234", "2001:db8::2, 203.0.113.9, 10.0.0.3", "", "203.0.113.9"},
+		{"10.0.0.1:1234", "[2001:db8::3]:443", "", "2001:db8::3"},
+		{"10.0.0.1:1234", "10.0.0.3, 192.168.1.1", "", "10.0.0.3"},
+		{"10.0.0.1:1234", "unknown", "", "10.0.0.1"},
+		{"192.0.2.1:1234", "203.0.113.5", "198.51.100.7", "192.0.2.1"},
+		{"@", "203.0.113.5", "", "203.0.113.5"},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest("GET", "/validate/DE89370400440532013000", nil)
+		req.RemoteAddr = c.remoteAddr
+		if c.forwarded != "" {
+			req.Header.Set("X-Forwarded-For", c.forwarded)
+		}
+		if c.realIP != "" {
+			req.Header.Set("X-Real-IP", c.realIP)
+		}
+
+		if ip := clientIP(req); ip != c.expected {
+			t.Errorf("expected client ip %v for %v, got %v", c.expected, c, ip)
+		}
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	networks, err := parseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.1", "2001:db8::/32"})
+	if err != nil || len(networks) != 3 {
+		t.Errorf("failed to parse the trusted proxies %v", err)
+		t.FailNow()
+	}
+
+	if !networks[1].Contains(net.ParseIP("192.0.2.1")) || networks[1].Contains(net.ParseIP("192.0.2.2")) {
+		t.Errorf("expected a single address to match itself only, got %v", networks[1])
+	}
+
+	for _, invalid := range []string{"10.0.0.0/33", "proxy.local"} {
+		if _, err := parseTrustedProxies([]string{invalid}); err == nil {
+			t.Errorf("expected %v to be rejected", invalid)
+		}
+	}
+}
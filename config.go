@@ -0,0 +1,500 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	m "github.com/fourcube/goiban-service/metrics"
+)
+
+const (
+	DEFAULT_CACHE_TTL     = 5 * time.Minute
+	DEFAULT_CACHE_CLEANUP = 30 * time.Second
+
+	DEFAULT_SHUTDOWN_TIMEOUT = 10 * time.Second
+
+	DEFAULT_DB_MAX_OPEN_CONNS    = 25
+	DEFAULT_DB_MAX_IDLE_CONNS    = 5
+	DEFAULT_DB_CONN_MAX_LIFETIME = 5 * time.Minute
+
+	DEFAULT_NEGATIVE_CACHE_TTL = time.Minute
+
+	// DEFAULT_MAX_IBAN_LENGTH leaves some slack above the longest valid IBAN (34 characters)
+	DEFAULT_MAX_IBAN_LENGTH = 40
+
+	DEFAULT_READ_TIMEOUT  = 10 * time.Second
+	DEFAULT_WRITE_TIMEOUT = 15 * time.Second
+	DEFAULT_IDLE_TIMEOUT  = 60 * time.Second
+
+	DEFAULT_DB_RETRIES     = 2
+	DEFAULT_DB_RETRY_DELAY = 50 * time.Millisecond
+
+	DEFAULT_DB_LOOKUP_TIMEOUT = 3 * time.Second
+
+	DEFAULT_HTTP_CACHE_MAX_AGE         = time.Hour
+	DEFAULT_HTTP_CACHE_INVALID_MAX_AGE = 5 * time.Minute
+
+	DEFAULT_DB_QUEUE_TIMEOUT = 500 * time.Millisecond
+
+	// DEFAULT_CACHE_MAX_ENTRIES bounds the memory used by the in-memory cache
+	DEFAULT_CACHE_MAX_ENTRIES = 100000
+
+	DEFAULT_STATSD_ADDRESS = "127.0.0.1:8125"
+
+	// DEFAULT_MAX_BODY_SIZE is the limit of POST request bodies in bytes
+	DEFAULT_MAX_BODY_SIZE = 4 << 20
+
+	// DEFAULT_DB_BREAKER_THRESHOLD failed lookups in a row open the circuit
+	// breaker for DEFAULT_DB_BREAKER_COOLDOWN
+	DEFAULT_DB_BREAKER_THRESHOLD = 5
+	DEFAULT_DB_BREAKER_COOLDOWN  = 30 * time.Second
+
+	// DEFAULT_MAINTENANCE_RETRY_AFTER is sent as Retry-After in maintenance mode
+	DEFAULT_MAINTENANCE_RETRY_AFTER = 5 * time.Minute
+
+	// The failure webhook is called when at least DEFAULT_FAILURE_WEBHOOK_THRESHOLD
+	// percent of the DEFAULT_FAILURE_WEBHOOK_MINIMUM or more results of a
+	// client within DEFAULT_FAILURE_WEBHOOK_WINDOW are invalid
+	DEFAULT_FAILURE_WEBHOOK_THRESHOLD = 50
+	DEFAULT_FAILURE_WEBHOOK_MINIMUM   = 20
+	DEFAULT_FAILURE_WEBHOOK_WINDOW    = 5 * time.Minute
+)
+
+// Config holds the settings of the service.
+//
+// Settings are read from the config file first, environment variables and
+// positional command line arguments override the values from the file.
+type Config struct {
+	Port            string `json:"port"`
+	DBUrl           string `json:"dbUrl"`
+	Env             string `json:"env"`
+	KeenProjectID   string `json:"keenProjectID"`
+	KeenWriteAPIKey string `json:"keenWriteAPIKey"`
+
+	// CacheTTL is the expiration of cached validation results, 0 disables the cache
+	CacheTTL Duration `json:"cacheTTL"`
+	// CacheCleanup is the interval in which expired cache entries are removed
+	CacheCleanup Duration `json:"cacheCleanup"`
+	// ShutdownTimeout is the grace period for in-flight requests on shutdown
+	ShutdownTimeout Duration `json:"shutdownTimeout"`
+	// RedisURL selects the Redis cache (e.g. redis://localhost:6379/0) instead of the in-memory cache
+	RedisURL string `json:"redisUrl"`
+
+	AllowedOrigins []string `json:"allowedOrigins"`
+
+	// APIKeys maps the accepted API keys to their labels, authentication is disabled when empty
+	APIKeys map[string]string `json:"apiKeys"`
+
+	// MaskIban masks IBANs before they are written to logs or metrics
+	MaskIban bool `json:"maskIban"`
+
+	// Connection pool settings of the database
+	DBMaxOpenConns    int      `json:"dbMaxOpenConns"`
+	DBMaxIdleConns    int      `json:"dbMaxIdleConns"`
+	DBConnMaxLifetime Duration `json:"dbConnMaxLifetime"`
+
+	// NegativeCacheTTL is the expiration of cached invalid results, 0 disables caching them
+	NegativeCacheTTL Duration `json:"negativeCacheTTL"`
+
+	// MaxIbanLength is the longest input accepted for validation, 0 disables the check
+	MaxIbanLength int `json:"maxIbanLength"`
+
+	// BindAddr is the host part of the listen address, empty listens on all interfaces
+	BindAddr string `json:"bindAddr"`
+
+	// TLSCert and TLSKey are the paths of the certificate and private key,
+	// TLS is enabled when both are set
+	TLSCert string `json:"tlsCert"`
+	TLSKey  string `json:"tlsKey"`
+
+	// TLSRedirectPort optionally serves redirects from plain HTTP to HTTPS
+	TLSRedirectPort string `json:"tlsRedirectPort"`
+
+	// AllowedCountries restricts validation to IBANs of these countries, all are allowed when empty
+	AllowedCountries []string `json:"allowedCountries"`
+
+	// StaticDir is a directory served as static frontend instead of the
+	// files embedded in the binary
+	StaticDir string `json:"staticDir"`
+
+	// ServeStatic enables or disables serving the static frontend. When
+	// unset the files are served if Env is Live or Test.
+	ServeStatic *bool `json:"serveStatic"`
+
+	// Metrics enables the metrics registers and endpoints. When disabled no
+	// data about validations is retained or sent to Keen.
+	Metrics bool `json:"metrics"`
+
+	// ReadTimeout, WriteTimeout and IdleTimeout limit how long a client
+	// connection may be used, see http.Server
+	ReadTimeout  Duration `json:"readTimeout"`
+	WriteTimeout Duration `json:"writeTimeout"`
+	IdleTimeout  Duration `json:"idleTimeout"`
+
+	// BasePath is the path prefix all routes are mounted under, e.g. /iban-api
+	BasePath string `json:"basePath"`
+
+	// DBRetries is the number of retries of database operations failing with
+	// transient (connection) errors, DBRetryDelay the delay before the first
+	// retry which doubles with every further retry
+	DBRetries    int      `json:"dbRetries"`
+	DBRetryDelay Duration `json:"dbRetryDelay"`
+
+	// Warmup preloads caches and opens the database connection before the
+	// server accepts requests. The bank lists of WarmupBanks are preloaded.
+	Warmup      bool     `json:"warmup"`
+	WarmupBanks []string `json:"warmupBanks"`
+
+	// Events are sent to Keen in batches every KeenFlushInterval or as
+	// soon as KeenBatchSize events are pending
+	KeenFlushInterval Duration `json:"keenFlushInterval"`
+	KeenBatchSize     int      `json:"keenBatchSize"`
+
+	// AccessLog is the file the access log is appended to, "-" writes it
+	// to stdout. AccessLogFormat is "combined" or "common".
+	AccessLog       string `json:"accessLog"`
+	AccessLogFormat string `json:"accessLogFormat"`
+
+	// DBLookupTimeout is the deadline of a single bank code or BIC lookup,
+	// 0 disables it
+	DBLookupTimeout Duration `json:"dbLookupTimeout"`
+
+	// Validation responses may be cached by browsers and CDNs for
+	// HTTPCacheMaxAge, invalid results for HTTPCacheInvalidMaxAge. 0 sends
+	// no caching headers.
+	HTTPCacheMaxAge        Duration `json:"httpCacheMaxAge"`
+	HTTPCacheInvalidMaxAge Duration `json:"httpCacheInvalidMaxAge"`
+
+	// KeenCollection is the Keen collection of the events, the env is used
+	// when it is empty
+	KeenCollection string `json:"keenCollection"`
+
+	// DBMaxConcurrency limits the number of validations looking up bank
+	// data at the same time, 0 doesn't limit them. Further validations wait
+	// up to DBQueueTimeout for a free slot before failing with DB_BUSY.
+	DBMaxConcurrency int      `json:"dbMaxConcurrency"`
+	DBQueueTimeout   Duration `json:"dbQueueTimeout"`
+
+	// Tracing exports OpenTelemetry spans of the requests and bank data
+	// lookups via OTLP/HTTP. The exporter is configured with the standard
+	// OTEL_EXPORTER_OTLP_* environment variables.
+	Tracing bool `json:"tracing"`
+
+	// DefaultGetBIC and DefaultValidateBankCode are used when a validation
+	// request doesn't set getBIC or validateBankCode
+	DefaultGetBIC           bool `json:"defaultGetBIC"`
+	DefaultValidateBankCode bool `json:"defaultValidateBankCode"`
+
+	// TrustedProxies are the CIDR ranges (or addresses) of the proxies whose
+	// X-Forwarded-For and X-Real-IP headers are honored. The headers of other
+	// clients are ignored.
+	TrustedProxies []string `json:"trustedProxies"`
+
+	// GRPCPort is the port of the optional gRPC server, it is disabled when empty
+	GRPCPort string `json:"grpcPort"`
+
+	// CacheMaxEntries is the maximum number of results held by the in-memory
+	// cache, the least recently used results are evicted when it is full. 0
+	// doesn't limit the cache.
+	CacheMaxEntries int `json:"cacheMaxEntries"`
+
+	// MetricsBackend receives the validation events: METRICS_BACKEND_INMEM,
+	// METRICS_BACKEND_KEEN or METRICS_BACKEND_STATSD. When it is empty Keen is
+	// used if its credentials are set, the in-memory register otherwise.
+	MetricsBackend string `json:"metricsBackend"`
+
+	// StatsdAddress is the host:port of the statsd agent, StatsdPrefix is
+	// prepended to the metric names
+	StatsdAddress string `json:"statsdAddress"`
+	StatsdPrefix  string `json:"statsdPrefix"`
+
+	// MaxBodySize limits the size of request bodies in bytes, larger bodies
+	// are rejected with HTTP 413. 0 disables the limit.
+	MaxBodySize int `json:"maxBodySize"`
+
+	// After DBBreakerThreshold failed bank data lookups in a row the lookups
+	// fail with DB_UNAVAILABLE without touching the database for
+	// DBBreakerCooldown, then a single lookup probes it. 0 disables the
+	// circuit breaker.
+	DBBreakerThreshold int      `json:"dbBreakerThreshold"`
+	DBBreakerCooldown  Duration `json:"dbBreakerCooldown"`
+
+	// In maintenance mode the validation and calculation routes answer with
+	// HTTP 503 and a Retry-After of MaintenanceRetryAfter, /health is not
+	// affected. Both can be changed by reloading the config.
+	Maintenance           bool     `json:"maintenance"`
+	MaintenanceRetryAfter Duration `json:"maintenanceRetryAfter"`
+
+	// CacheKeyHash stores the cached results under the SHA-256 of their key,
+	// so the keys have the same length regardless of the input
+	CacheKeyHash bool `json:"cacheKeyHash"`
+
+	// FailureWebhookURL receives a POST when at least FailureWebhookThreshold
+	// percent of the results of a client are invalid, counted over the last
+	// FailureWebhookWindow once it has FailureWebhookMinimum results.
+	// Clients are identified by their API key label or IP address. The
+	// webhook is disabled when the URL is empty.
+	FailureWebhookURL       string   `json:"failureWebhookURL"`
+	FailureWebhookThreshold int      `json:"failureWebhookThreshold"`
+	FailureWebhookMinimum   int      `json:"failureWebhookMinimum"`
+	FailureWebhookWindow    Duration `json:"failureWebhookWindow"`
+}
+
+// Duration is a time.Duration which is written as a string (e.g. "10m") in JSON
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	err := json.Unmarshal(data, &value)
+	if err != nil {
+		return err
+	}
+
+	d.Duration, err = time.ParseDuration(value)
+	return err
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+var cfg = defaultConfig()
+
+func defaultConfig() *Config {
+	return &Config{
+		Env:                     "Test",
+		CacheTTL:                Duration{DEFAULT_CACHE_TTL},
+		CacheCleanup:            Duration{DEFAULT_CACHE_CLEANUP},
+		ShutdownTimeout:         Duration{DEFAULT_SHUTDOWN_TIMEOUT},
+		AllowedOrigins:          []string{"*"},
+		MaskIban:                true,
+		DBMaxOpenConns:          DEFAULT_DB_MAX_OPEN_CONNS,
+		DBMaxIdleConns:          DEFAULT_DB_MAX_IDLE_CONNS,
+		DBConnMaxLifetime:       Duration{DEFAULT_DB_CONN_MAX_LIFETIME},
+		NegativeCacheTTL:        Duration{DEFAULT_NEGATIVE_CACHE_TTL},
+		MaxIbanLength:           DEFAULT_MAX_IBAN_LENGTH,
+		Metrics:                 true,
+		ReadTimeout:             Duration{DEFAULT_READ_TIMEOUT},
+		WriteTimeout:            Duration{DEFAULT_WRITE_TIMEOUT},
+		IdleTimeout:             Duration{DEFAULT_IDLE_TIMEOUT},
+		DBRetries:               DEFAULT_DB_RETRIES,
+		DBRetryDelay:            Duration{DEFAULT_DB_RETRY_DELAY},
+		KeenFlushInterval:       Duration{m.DEFAULT_KEEN_FLUSH_INTERVAL},
+		KeenBatchSize:           m.DEFAULT_KEEN_BATCH_SIZE,
+		AccessLogFormat:         ACCESS_LOG_COMBINED,
+		DBLookupTimeout:         Duration{DEFAULT_DB_LOOKUP_TIMEOUT},
+		HTTPCacheMaxAge:         Duration{DEFAULT_HTTP_CACHE_MAX_AGE},
+		HTTPCacheInvalidMaxAge:  Duration{DEFAULT_HTTP_CACHE_INVALID_MAX_AGE},
+		DBQueueTimeout:          Duration{DEFAULT_DB_QUEUE_TIMEOUT},
+		CacheMaxEntries:         DEFAULT_CACHE_MAX_ENTRIES,
+		StatsdAddress:           DEFAULT_STATSD_ADDRESS,
+		StatsdPrefix:            m.DEFAULT_STATSD_PREFIX,
+		MaxBodySize:             DEFAULT_MAX_BODY_SIZE,
+		DBBreakerThreshold:      DEFAULT_DB_BREAKER_THRESHOLD,
+		DBBreakerCooldown:       Duration{DEFAULT_DB_BREAKER_COOLDOWN},
+		MaintenanceRetryAfter:   Duration{DEFAULT_MAINTENANCE_RETRY_AFTER},
+		CacheKeyHash:            true,
+		FailureWebhookThreshold: DEFAULT_FAILURE_WEBHOOK_THRESHOLD,
+		FailureWebhookMinimum:   DEFAULT_FAILURE_WEBHOOK_MINIMUM,
+		FailureWebhookWindow:    Duration{DEFAULT_FAILURE_WEBHOOK_WINDOW},
+	}
+}
+
+// Reads the JSON config file at path into config.
+func loadConfigFile(config *Config, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return json.NewDecoder(file).Decode(config)
+}
+
+// Reads the settings that can be provided through environment variables.
+func loadEnv(config *Config) {
+	config.CacheTTL.Duration = envDuration("GOIBAN_CACHE_TTL", config.CacheTTL.Duration)
+	config.CacheCleanup.Duration = envDuration("GOIBAN_CACHE_CLEANUP", config.CacheCleanup.Duration)
+	config.ShutdownTimeout.Duration = envDuration("GOIBAN_SHUTDOWN_TIMEOUT", config.ShutdownTimeout.Duration)
+	config.RedisURL = envString("GOIBAN_REDIS_URL", config.RedisURL)
+	config.AllowedOrigins = envList("GOIBAN_CORS_ORIGINS", config.AllowedOrigins)
+	config.MaskIban = envBool("GOIBAN_MASK_IBAN", config.MaskIban)
+
+	if keys := os.Getenv("GOIBAN_API_KEYS"); keys != "" {
+		config.APIKeys = parseAPIKeys(keys)
+	}
+
+	config.DBMaxOpenConns = envInt("GOIBAN_DB_MAX_OPEN_CONNS", config.DBMaxOpenConns)
+	config.DBMaxIdleConns = envInt("GOIBAN_DB_MAX_IDLE_CONNS", config.DBMaxIdleConns)
+	config.DBConnMaxLifetime.Duration = envDuration("GOIBAN_DB_CONN_MAX_LIFETIME", config.DBConnMaxLifetime.Duration)
+	config.NegativeCacheTTL.Duration = envDuration("GOIBAN_NEGATIVE_CACHE_TTL", config.NegativeCacheTTL.Duration)
+	config.MaxIbanLength = envInt("GOIBAN_MAX_IBAN_LENGTH", config.MaxIbanLength)
+	config.BindAddr = envString("GOIBAN_BIND_ADDR", config.BindAddr)
+	config.TLSCert = envString("GOIBAN_TLS_CERT", config.TLSCert)
+	config.TLSKey = envString("GOIBAN_TLS_KEY", config.TLSKey)
+	config.TLSRedirectPort = envString("GOIBAN_TLS_REDIRECT_PORT", config.TLSRedirectPort)
+	config.AllowedCountries = envList("GOIBAN_ALLOWED_COUNTRIES", config.AllowedCountries)
+	config.StaticDir = envString("GOIBAN_STATIC_DIR", config.StaticDir)
+	if os.Getenv("GOIBAN_SERVE_STATIC") != "" {
+		enabled := envBool("GOIBAN_SERVE_STATIC", false)
+		config.ServeStatic = &enabled
+	}
+
+	config.DBUrl = envString("GOIBAN_DB_URL", config.DBUrl)
+	config.KeenProjectID = envString("GOIBAN_KEEN_PROJECT_ID", config.KeenProjectID)
+	config.KeenWriteAPIKey = envString("GOIBAN_KEEN_WRITE_KEY", config.KeenWriteAPIKey)
+	switch strings.ToLower(os.Getenv("GOIBAN_METRICS")) {
+	case "off":
+		config.Metrics = false
+	case "on":
+		config.Metrics = true
+	default:
+		config.Metrics = envBool("GOIBAN_METRICS", config.Metrics)
+	}
+
+	config.ReadTimeout.Duration = envDuration("GOIBAN_READ_TIMEOUT", config.ReadTimeout.Duration)
+	config.WriteTimeout.Duration = envDuration("GOIBAN_WRITE_TIMEOUT", config.WriteTimeout.Duration)
+	config.IdleTimeout.Duration = envDuration("GOIBAN_IDLE_TIMEOUT", config.IdleTimeout.Duration)
+	config.BasePath = envString("GOIBAN_BASE_PATH", config.BasePath)
+	config.DBRetries = envInt("GOIBAN_DB_RETRIES", config.DBRetries)
+	config.DBRetryDelay.Duration = envDuration("GOIBAN_DB_RETRY_DELAY", config.DBRetryDelay.Duration)
+	config.Warmup = envBool("GOIBAN_WARMUP", config.Warmup)
+	config.WarmupBanks = envList("GOIBAN_WARMUP_BANKS", config.WarmupBanks)
+	config.KeenFlushInterval.Duration = envDuration("GOIBAN_KEEN_FLUSH_INTERVAL", config.KeenFlushInterval.Duration)
+	config.KeenBatchSize = envInt("GOIBAN_KEEN_BATCH_SIZE", config.KeenBatchSize)
+	config.AccessLog = envString("GOIBAN_ACCESS_LOG", config.AccessLog)
+	config.AccessLogFormat = envString("GOIBAN_ACCESS_LOG_FORMAT", config.AccessLogFormat)
+	config.DBLookupTimeout.Duration = envDuration("GOIBAN_DB_LOOKUP_TIMEOUT", config.DBLookupTimeout.Duration)
+	config.HTTPCacheMaxAge.Duration = envDuration("GOIBAN_HTTP_CACHE_MAX_AGE", config.HTTPCacheMaxAge.Duration)
+	config.HTTPCacheInvalidMaxAge.Duration = envDuration("GOIBAN_HTTP_CACHE_INVALID_MAX_AGE", config.HTTPCacheInvalidMaxAge.Duration)
+	config.KeenCollection = envString("GOIBAN_KEEN_COLLECTION", config.KeenCollection)
+	config.DBMaxConcurrency = envInt("GOIBAN_DB_MAX_CONCURRENCY", config.DBMaxConcurrency)
+	config.DBQueueTimeout.Duration = envDuration("GOIBAN_DB_QUEUE_TIMEOUT", config.DBQueueTimeout.Duration)
+	config.Tracing = envBool("GOIBAN_TRACING", config.Tracing)
+	config.DefaultGetBIC = envBool("GOIBAN_DEFAULT_GETBIC", config.DefaultGetBIC)
+	config.DefaultValidateBankCode = envBool("GOIBAN_DEFAULT_VALIDATE_BANKCODE", config.DefaultValidateBankCode)
+	config.TrustedProxies = envList("GOIBAN_TRUSTED_PROXIES", config.TrustedProxies)
+	config.GRPCPort = envString("GOIBAN_GRPC_PORT", config.GRPCPort)
+	config.CacheMaxEntries = envInt("GOIBAN_CACHE_MAX_ENTRIES", config.CacheMaxEntries)
+	config.MetricsBackend = envString("GOIBAN_METRICS_BACKEND", config.MetricsBackend)
+	config.StatsdAddress = envString("GOIBAN_STATSD_ADDRESS", config.StatsdAddress)
+	config.StatsdPrefix = envString("GOIBAN_STATSD_PREFIX", config.StatsdPrefix)
+	config.MaxBodySize = envInt("GOIBAN_MAX_BODY_SIZE", config.MaxBodySize)
+	config.DBBreakerThreshold = envInt("GOIBAN_DB_BREAKER_THRESHOLD", config.DBBreakerThreshold)
+	config.DBBreakerCooldown.Duration = envDuration("GOIBAN_DB_BREAKER_COOLDOWN", config.DBBreakerCooldown.Duration)
+	config.Maintenance = envBool("GOIBAN_MAINTENANCE", config.Maintenance)
+	config.MaintenanceRetryAfter.Duration = envDuration("GOIBAN_MAINTENANCE_RETRY_AFTER", config.MaintenanceRetryAfter.Duration)
+	config.CacheKeyHash = envBool("GOIBAN_CACHE_KEY_HASH", config.CacheKeyHash)
+	config.FailureWebhookURL = envString("GOIBAN_FAILURE_WEBHOOK_URL", config.FailureWebhookURL)
+	config.FailureWebhookThreshold = envInt("GOIBAN_FAILURE_WEBHOOK_THRESHOLD", config.FailureWebhookThreshold)
+	config.FailureWebhookMinimum = envInt("GOIBAN_FAILURE_WEBHOOK_MINIMUM", config.FailureWebhookMinimum)
+	config.FailureWebhookWindow.Duration = envDuration("GOIBAN_FAILURE_WEBHOOK_WINDOW", config.FailureWebhookWindow.Duration)
+}
+
+// Reads the positional command line arguments
+// <port> <dburl> [<env>] [keenProjectID] [keenWriteAPIKey] into config.
+// Omitted arguments keep the values from the environment or config file.
+func loadArgs(config *Config, args []string) {
+	if len(args) > 0 {
+		config.Port = args[0]
+	}
+
+	if len(args) > 1 {
+		config.DBUrl = args[1]
+	}
+
+	if len(args) > 2 {
+		config.Env = args[2]
+	}
+
+	if len(args) > 3 {
+		config.KeenProjectID = args[3]
+	}
+
+	if len(args) > 4 {
+		config.KeenWriteAPIKey = args[4]
+	}
+}
+
+// Returns the environment variable name or fallback when it is not set.
+func envString(name string, fallback string) string {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	return value
+}
+
+// Parses the environment variable name as a duration (e.g. "10m"). Returns
+// fallback when the variable is not set.
+func envDuration(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		log.Fatalf("Invalid duration %q for %v", value, name)
+	}
+
+	return d
+}
+
+// Parses the environment variable name as an integer. Returns fallback
+// when the variable is not set.
+func envInt(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("Invalid number %q for %v", value, name)
+	}
+
+	return i
+}
+
+// Parses the environment variable name as a boolean ("1", "true", "0",
+// "false", ...). Returns fallback when the variable is not set.
+func envBool(name string, fallback bool) bool {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("Invalid boolean %q for %v", value, name)
+	}
+
+	return b
+}
+
+// Splits the environment variable name at commas. Returns fallback when
+// the variable is not set.
+func envList(name string, fallback []string) []string {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	var list []string
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry != "" {
+			list = append(list, entry)
+		}
+	}
+
+	return list
+}
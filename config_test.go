@@ -0,0 +1,74 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigFile(t *testing.T) {
+	file, _ := ioutil.TempFile("", "goiban-config")
+	defer os.Remove(file.Name())
+
+	file.WriteString(`{"port": "8080", "dbUrl": "root:root@/goiban", "cacheTTL": "1h", "allowedOrigins": ["https://openiban.com"]}`)
+	file.Close()
+
+	config := defaultConfig()
+	err := loadConfigFile(config, file.Name())
+
+	if err != nil {
+		t.Fatalf("failed to load config %v", err)
+	}
+
+	if config.Port != "8080" || config.DBUrl != "root:root@/goiban" {
+		t.Errorf("unexpected config %v", config)
+	}
+
+	if config.CacheTTL.Duration != time.Hour {
+		t.Errorf("expected cache TTL of 1h, got %v", config.CacheTTL)
+	}
+
+	if config.CacheCleanup.Duration != DEFAULT_CACHE_CLEANUP {
+		t.Errorf("expected default cache cleanup, got %v", config.CacheCleanup)
+	}
+
+	if len(config.AllowedOrigins) != 1 || config.AllowedOrigins[0] != "https://openiban.com" {
+		t.Errorf("unexpected allowed origins %v", config.AllowedOrigins)
+	}
+}
+
+func TestArgsOverrideConfigFile(t *testing.T) {
+	config := defaultConfig()
+	config.Port = "8080"
+	config.DBUrl = "root:root@/goiban"
+
+	loadArgs(config, []string{"9090"})
+
+	if config.Port != "9090" {
+		t.Errorf("expected port 9090, got %v", config.Port)
+	}
+
+	if config.DBUrl != "root:root@/goiban" {
+		t.Errorf("expected db url from config file, got %v", config.DBUrl)
+	}
+}
+
+func TestDBUrlFromEnv(t *testing.T) {
+	os.Setenv("GOIBAN_DB_URL", "root:root@/goiban")
+	defer os.Unsetenv("GOIBAN_DB_URL")
+
+	config := defaultConfig()
+	loadEnv(config)
+	loadArgs(config, []string{"8080"})
+
+	if config.DBUrl != "root:root@/goiban" {
+		t.Errorf("expected db url from the environment, got %v", config.DBUrl)
+	}
+
+	loadArgs(config, []string{"8080", "other:other@/goiban"})
+
+	if config.DBUrl != "other:other@/goiban" {
+		t.Errorf("expected the argument to override the environment, got %v", config.DBUrl)
+	}
+}
@@ -3,17 +3,24 @@ package main
 import (
 	"encoding/json"
 	"net/http"
+	"sync"
 
 	"github.com/fourcube/goiban"
 	"github.com/julienschmidt/httprouter"
 )
 
+var (
+	countriesData []byte
+	countriesErr  error
+	countriesOnce sync.Once
+)
+
 func countryCodeHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
 	w.Header().Add("Content-Type", "application/json; charset=utf-8")
 	// Allow CORS
-	w.Header().Set("Access-Control-Allow-Origin", "*")
+	allowOrigin(w, r)
 
-	data, err := json.Marshal(goiban.COUNTRY_TO_CC_MAP)
+	data, err := countriesResponse()
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		r.Body.Close()
@@ -23,3 +30,14 @@ func countryCodeHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Par
 	w.WriteHeader(http.StatusOK)
 	w.Write(data)
 }
+
+// Returns the JSON of the /countries response. It is marshalled once,
+// goiban.COUNTRY_TO_CC_MAP is compiled into the binary and never changes
+// at runtime.
+func countriesResponse() ([]byte, error) {
+	countriesOnce.Do(func() {
+		countriesData, countriesErr = json.Marshal(goiban.COUNTRY_TO_CC_MAP)
+	})
+
+	return countriesData, countriesErr
+}
@@ -23,3 +23,16 @@ func TestReturnsCountryCodeMap(t *testing.T) {
 		t.Errorf("Received no country codes")
 	}
 }
+
+func TestCountriesResponseIsMemoized(t *testing.T) {
+	first, err := countriesResponse()
+	if err != nil {
+		t.Errorf("failed to marshal the countries %v", err)
+		t.FailNow()
+	}
+
+	second, _ := countriesResponse()
+	if &first[0] != &second[0] {
+		t.Errorf("expected the countries to be marshalled once")
+	}
+}
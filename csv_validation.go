@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// CSV_RESULT_HEADER is the header row of the results of /validate/csv
+var CSV_RESULT_HEADER = []string{"iban", "valid", "country", "messages"}
+
+var errNoCSVFile = errors.New("no file in multipart body")
+
+// Processes requests to the /validate/csv url. The body is a CSV file,
+// either raw or as the file of a multipart/form-data upload. The IBANs are
+// read from the column with the 0-based index column (default 0), with
+// header=true the first row is skipped. The response is a CSV file with
+// the IBAN, validity, country and messages of every row in order. The
+// validation options are the same as for /validate.
+func csvValidationHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	// Allow CORS
+	allowOrigin(w, r)
+
+	// the body is the CSV file, the options only come from the URL. With
+	// the form set, FormValue doesn't try to parse a multipart body which
+	// is read by readCSVColumn.
+	r.Form = r.URL.Query()
+
+	column := 0
+	if value := r.URL.Query().Get("column"); value != "" {
+		var err error
+		column, err = strconv.Atoi(value)
+		if err != nil || column < 0 {
+			writeError(w, r, http.StatusBadRequest, ERROR_INVALID_REQUEST, "Expected a non-negative column index.")
+			return
+		}
+	}
+
+	ibans, err := readCSVColumn(r, column, toBoolean(r.URL.Query().Get("header")))
+	r.Body.Close()
+
+	if bodyTooLarge(err) {
+		writeBodyTooLarge(w, r)
+		return
+	}
+
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, ERROR_INVALID_REQUEST, "Expected a CSV file of IBANs: "+err.Error())
+		return
+	}
+
+	if len(ibans) > MAX_BATCH_SIZE {
+		writeError(w, r, http.StatusRequestEntityTooLarge, ERROR_TOO_MANY_IBANS, "Batch exceeds the maximum size of "+strconv.Itoa(MAX_BATCH_SIZE)+" IBANs.")
+		return
+	}
+
+	config := validationConfig(r)
+
+	var buf bytes.Buffer
+	out := csv.NewWriter(&buf)
+	out.Write(CSV_RESULT_HEADER)
+
+	for _, iban := range ibans {
+		if exceedsMaxLength(iban) {
+			// the input itself is not echoed back
+			out.Write([]string{"", "false", "", fmt.Sprintf("Input exceeds the maximum length of %d characters.", cfg.MaxIbanLength)})
+			continue
+		}
+
+		strRes, err := validate(r.Context(), iban, config)
+		if err != nil {
+			writeLookupError(w, r, err)
+			return
+		}
+
+		var result ValidationResponse
+		json.Unmarshal([]byte(strRes), &result)
+
+		out.Write(csvResultRow(iban, &result))
+	}
+	out.Flush()
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// Returns the CSV row of a validation result. iban is the input as
+// uploaded, the messages are joined by "; ".
+func csvResultRow(iban string, result *ValidationResponse) []string {
+	country := ""
+	if len(result.Iban) >= 2 {
+		country = result.Iban[:2]
+	}
+
+	return []string{iban, strconv.FormatBool(result.Valid), country, strings.Join(result.Messages, "; ")}
+}
+
+// Reads the values of a column of the uploaded CSV file. Blank lines are
+// skipped, rows too short for the column are an error. At most one value
+// more than MAX_BATCH_SIZE is read, so oversized uploads are rejected
+// without reading them completely.
+func readCSVColumn(r *http.Request, column int, header bool) ([]string, error) {
+	body, err := csvBody(r)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := csv.NewReader(body)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var values []string
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return values, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if header && row == 1 {
+			continue
+		}
+
+		if column >= len(record) {
+			return nil, fmt.Errorf("row %d has no column %d", row, column)
+		}
+
+		values = append(values, record[column])
+		if len(values) > MAX_BATCH_SIZE {
+			return values, nil
+		}
+	}
+}
+
+// Returns the CSV file of the request body, the first file of a
+// multipart/form-data body or the body itself
+func csvBody(r *http.Request) (io.Reader, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		return r.Body, nil
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil, errNoCSVFile
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if part.FileName() != "" {
+			return part, nil
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCSVValidation(t *testing.T) {
+	body := "name,iban\nAlice,DE89370400440532013000\nBob,DE89370400440532013001\n"
+
+	resp, err := http.Post(server.URL+"/validate/csv?header=true&column=1", "text/csv", strings.NewReader(body))
+	if err != nil {
+		t.Errorf("failed to validate csv %v", err)
+		t.FailNow()
+	}
+
+	rows, err := csv.NewReader(resp.Body).ReadAll()
+	resp.Body.Close()
+
+	if err != nil {
+		t.Errorf("failed to read csv %v", err)
+		t.FailNow()
+	}
+
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/csv") {
+		t.Errorf("expected a CSV response, got %v %v", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	if len(rows) != 3 || strings.Join(rows[0], ",") != "iban,valid,country,messages" {
+		t.Fatalf("unexpected rows %v", rows)
+	}
+
+	if rows[1][0] != "DE89370400440532013000" || rows[1][1] != "true" || rows[1][2] != "DE" {
+		t.Errorf("unexpected result of the valid IBAN %v", rows[1])
+	}
+
+	if rows[2][1] != "false" || rows[2][3] == "" {
+		t.Errorf("expected the invalid IBAN to fail with messages, got %v", rows[2])
+	}
+}
+
+func TestCSVValidationMultipart(t *testing.T) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, _ := writer.CreateFormFile("file", "ibans.csv")
+	part.Write([]byte("DE89370400440532013000\n"))
+	writer.Close()
+
+	resp, err := http.Post(server.URL+"/validate/csv", writer.FormDataContentType(), &body)
+	if err != nil {
+		t.Errorf("failed to validate csv %v", err)
+		t.FailNow()
+	}
+
+	rows, err := csv.NewReader(resp.Body).ReadAll()
+	resp.Body.Close()
+
+	if err != nil || len(rows) != 2 || rows[1][1] != "true" {
+		t.Errorf("unexpected result %v %v", rows, err)
+	}
+
+	// the options of the URL reach the validation
+	c.Delete(cacheKey("DE89370400440532013000", validationConfig(httptest.NewRequest("GET", "/?getBIC=true", nil))))
+
+	body.Reset()
+	writer = multipart.NewWriter(&body)
+	part, _ = writer.CreateFormFile("file", "ibans.csv")
+	part.Write([]byte("DE89370400440532013000\n"))
+	writer.Close()
+
+	resp, err = http.Post(server.URL+"/validate/csv?getBIC=true", writer.FormDataContentType(), &body)
+	if err != nil {
+		t.Errorf("failed to validate csv %v", err)
+		t.FailNow()
+	}
+	resp.Body.Close()
+
+	// without a database the BIC lookup fails, otherwise its result is cached
+	if db.Ping() != nil {
+		if resp.StatusCode == http.StatusOK {
+			t.Errorf("expected the BIC lookup to fail without a database")
+		}
+		return
+	}
+
+	if _, found := hitCache(cacheKey("DE89370400440532013000", validationConfig(httptest.NewRequest("GET", "/?getBIC=true", nil)))); !found {
+		t.Errorf("expected the result with getBIC to be cached")
+	}
+}
+
+func TestCSVValidationRejectsMissingColumn(t *testing.T) {
+	resp, err := http.Post(server.URL+"/validate/csv?column=2", "text/csv", strings.NewReader("DE89370400440532013000\n"))
+	if err != nil {
+		t.Errorf("failed to validate csv %v", err)
+		t.FailNow()
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status %v, got %v", http.StatusBadRequest, resp.StatusCode)
+	}
+}
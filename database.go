@@ -0,0 +1,381 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/fourcube/goiban"
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+// POSTGRES_DRIVER is the name of the PostgreSQL driver which accepts
+// MySQL style '?' placeholders.
+const POSTGRES_DRIVER = "goiban-postgres"
+
+// errDBTimeout is returned when a lookup exceeds cfg.DBLookupTimeout
+var errDBTimeout = errors.New("database lookup timed out")
+
+// errDBBusy is returned when no lookup slot became free within
+// cfg.DBQueueTimeout
+var errDBBusy = errors.New("too many concurrent database lookups")
+
+// errDBSchema is returned when the BANK_DATA table doesn't exist
+var errDBSchema = errors.New("bank data table is missing")
+
+// dbSlots limits the number of validations looking up bank data at the
+// same time, nil when they are not limited
+var dbSlots chan struct{}
+
+func init() {
+	sql.Register(POSTGRES_DRIVER, &rebindDriver{&pq.Driver{}})
+}
+
+// Chooses the database driver by looking at the scheme of the database url.
+//
+// postgres://... and postgresql://... select the PostgreSQL driver, every other
+// url is treated as a MySQL DSN.
+func driverName(dbUrl string) string {
+	if strings.HasPrefix(dbUrl, "postgres://") || strings.HasPrefix(dbUrl, "postgresql://") {
+		return POSTGRES_DRIVER
+	}
+
+	return "mysql"
+}
+
+func openDB(dbUrl string) (*sql.DB, error) {
+	return sql.Open(driverName(dbUrl), dbUrl)
+}
+
+// rebindDriver wraps a driver that expects $1, $2, ... placeholders so that
+// queries written with '?' placeholders (like the ones used by goiban)
+// can be run unchanged.
+type rebindDriver struct {
+	driver.Driver
+}
+
+func (d *rebindDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rebindConn{conn}, nil
+}
+
+// rebindConn rewrites the placeholders of every query before it reaches
+// the wrapped connection. The optional interfaces of the connection are
+// forwarded, so pings, contexts and the pool's connection checks behave as
+// with the unwrapped driver. A connection lacking one of them reports
+// driver.ErrSkip, so database/sql falls back to the plain methods.
+type rebindConn struct {
+	driver.Conn
+}
+
+var (
+	_ driver.Pinger             = &rebindConn{}
+	_ driver.ConnPrepareContext = &rebindConn{}
+	_ driver.QueryerContext     = &rebindConn{}
+	_ driver.ExecerContext      = &rebindConn{}
+	_ driver.ConnBeginTx        = &rebindConn{}
+	_ driver.SessionResetter    = &rebindConn{}
+	_ driver.Validator          = &rebindConn{}
+	_ driver.NamedValueChecker  = &rebindConn{}
+)
+
+func (c *rebindConn) Prepare(query string) (driver.Stmt, error) {
+	return c.Conn.Prepare(rebind(query))
+}
+
+func (c *rebindConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, rebind(query))
+	}
+
+	return c.Prepare(query)
+}
+
+func (c *rebindConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if queryer, ok := c.Conn.(driver.QueryerContext); ok {
+		return queryer.QueryContext(ctx, rebind(query), args)
+	}
+
+	return nil, driver.ErrSkip
+}
+
+func (c *rebindConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if execer, ok := c.Conn.(driver.ExecerContext); ok {
+		return execer.ExecContext(ctx, rebind(query), args)
+	}
+
+	return nil, driver.ErrSkip
+}
+
+func (c *rebindConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+
+	return nil
+}
+
+func (c *rebindConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+
+	if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) || opts.ReadOnly {
+		return nil, errors.New("the database driver doesn't support transaction options")
+	}
+
+	return c.Conn.Begin()
+}
+
+func (c *rebindConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+
+	return nil
+}
+
+func (c *rebindConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+
+	return true
+}
+
+func (c *rebindConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+
+	return driver.ErrSkip
+}
+
+// Replaces '?' placeholders with numbered $n placeholders. Question marks
+// inside of quoted strings are left untouched.
+func rebind(query string) string {
+	var buf strings.Builder
+	var quote rune
+	n := 0
+
+	for _, r := range query {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '?':
+			n++
+			buf.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		buf.WriteRune(r)
+	}
+
+	return buf.String()
+}
+
+// Runs the database operation op. Transient errors (e.g. a reset
+// connection) are retried up to cfg.DBRetries times with exponential
+// backoff, other errors like sql.ErrNoRows are returned immediately.
+func withDBRetry(op func() error) error {
+	delay := cfg.DBRetryDelay.Duration
+
+	err := op()
+	for attempt := 0; attempt < cfg.DBRetries && isTransientDBError(err); attempt++ {
+		time.Sleep(delay)
+		delay *= 2
+
+		err = op()
+	}
+
+	return err
+}
+
+// Returns a context with the deadline of a single lookup, canceled with
+// ctx, e.g. when the client of the request went away. The lookup timeout 0
+// disables the deadline.
+func lookupContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if cfg.DBLookupTimeout.Duration <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, cfg.DBLookupTimeout.Duration)
+}
+
+// Runs a goiban lookup on a copy of result and waits until the deadline
+// of the lookup context. goiban doesn't accept a context, so a lookup which
+// timed out finishes in the background and its result is discarded. The
+// lookup holds slot until it has finished, even after a timeout.
+func withLookupTimeout(ctx context.Context, slot *dbSlot, result *goiban.ValidationResult, lookup func(*goiban.ValidationResult) *goiban.ValidationResult) (*goiban.ValidationResult, error) {
+	ctx, cancel := lookupContext(ctx)
+	defer cancel()
+
+	copied := *result
+	copied.Messages = append([]string(nil), result.Messages...)
+	copied.CheckResults = make(map[string]bool, len(result.CheckResults))
+	for check, valid := range result.CheckResults {
+		copied.CheckResults[check] = valid
+	}
+
+	done := make(chan *goiban.ValidationResult, 1)
+	slot.hold()
+	go func() {
+		defer slot.release()
+		done <- lookup(&copied)
+	}()
+
+	select {
+	case looked := <-done:
+		return looked, nil
+	case <-ctx.Done():
+		if ctx.Err() == context.Canceled {
+			return result, ctx.Err()
+		}
+		return result, errDBTimeout
+	}
+}
+
+// Limits the number of concurrent bank data lookups to max, 0 removes the
+// limit.
+func limitDBConcurrency(max int) {
+	if max <= 0 {
+		dbSlots = nil
+		return
+	}
+
+	dbSlots = make(chan struct{}, max)
+}
+
+// dbSlot is a lookup slot taken by acquireDBSlot. The slot is freed when
+// it has been released by its lookup and by the goiban lookups it started.
+type dbSlot struct {
+	slots chan struct{}
+	refs  int32
+}
+
+// Keeps the slot taken until the matching call of release
+func (s *dbSlot) hold() {
+	atomic.AddInt32(&s.refs, 1)
+}
+
+// Frees the slot once every holder released it
+func (s *dbSlot) release() {
+	if atomic.AddInt32(&s.refs, -1) == 0 && s.slots != nil {
+		<-s.slots
+	}
+}
+
+// Waits up to cfg.DBQueueTimeout for a free lookup slot. The slot has to be
+// released again.
+func acquireDBSlot() (*dbSlot, error) {
+	slots := dbSlots
+	if slots == nil {
+		return &dbSlot{refs: 1}, nil
+	}
+
+	slot := &dbSlot{slots: slots, refs: 1}
+
+	select {
+	case slots <- struct{}{}:
+		return slot, nil
+	default:
+	}
+
+	if cfg.DBQueueTimeout.Duration <= 0 {
+		return nil, errDBBusy
+	}
+
+	timer := time.NewTimer(cfg.DBQueueTimeout.Duration)
+	defer timer.Stop()
+
+	select {
+	case slots <- struct{}{}:
+		return slot, nil
+	case <-timer.C:
+		return nil, errDBBusy
+	}
+}
+
+// Returns the error result and HTTP status of a failed bank data lookup.
+// Timeouts are reported separately from an unavailable database.
+func lookupError(err error, iban string) (*ValidationResponse, int) {
+	if err == errDBTimeout {
+		return errorResult(ERROR_DB_TIMEOUT, "Bank data lookup timed out.", iban), http.StatusGatewayTimeout
+	}
+
+	if err == errDBSchema {
+		return errorResult(ERROR_DB_SCHEMA, "Bank data table is missing.", iban), http.StatusInternalServerError
+	}
+
+	if err == errDBBusy {
+		return errorResult(ERROR_DB_BUSY, "Too many concurrent bank data lookups.", iban), http.StatusServiceUnavailable
+	}
+
+	return errorResult(ERROR_DB_UNAVAILABLE, "Bank data is currently unavailable.", iban), http.StatusServiceUnavailable
+}
+
+// Reports whether err was caused by a table which doesn't exist
+func isUndefinedTableError(err error) bool {
+	switch e := err.(type) {
+	case *mysql.MySQLError:
+		return e.Number == 1146
+	case *pq.Error:
+		return e.Code == "42P01"
+	}
+
+	return false
+}
+
+// Reports whether err was caused by a column which doesn't exist in the
+// schema
+func isUndefinedColumnError(err error) bool {
+	switch e := err.(type) {
+	case *mysql.MySQLError:
+		return e.Number == 1054
+	case *pq.Error:
+		return e.Code == "42703"
+	}
+
+	return false
+}
+
+// Reports whether err is a connection error which may succeed on retry
+func isTransientDBError(err error) bool {
+	if err == nil || err == sql.ErrNoRows {
+		return false
+	}
+
+	// a lookup which ran out of time or slots is not retried, neither is
+	// one of a missing table
+	if err == errDBTimeout || err == errDBBusy || err == errDBSchema || err == context.DeadlineExceeded || err == context.Canceled {
+		return false
+	}
+
+	if err == driver.ErrBadConn || err == mysql.ErrInvalidConn {
+		return true
+	}
+
+	if _, ok := err.(net.Error); ok {
+		return true
+	}
+
+	message := err.Error()
+	return strings.Contains(message, "connection reset") ||
+		strings.Contains(message, "broken pipe") ||
+		strings.Contains(message, "connection refused")
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	m "github.com/fourcube/goiban-service/metrics"
+	"github.com/julienschmidt/httprouter"
+)
+
+// Field limits of the EPC069-12 payload
+const (
+	EPC_MAX_NAME_LENGTH       = 70
+	EPC_MAX_REMITTANCE_LENGTH = 140
+)
+
+var amountPattern = regexp.MustCompile(`^[0-9]{1,9}(\.[0-9]{1,2})?$`)
+
+// Processes requests to the /epc-qr url. Returns the EPC069-12 payload of
+// a SEPA credit transfer to the IBAN, which is rendered as a QR code by the
+// client. The iban and name parameters are required, bic, amount (in EUR)
+// and remittance are optional.
+func epcQRHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	// Allow CORS
+	allowOrigin(w, r)
+
+	input := r.FormValue("iban")
+	if exceedsMaxLength(input) {
+		writeTooLong(w, r)
+		return
+	}
+
+	iban := normalizeIban(input)
+	if len(iban) == 0 {
+		res, _ := json.MarshalIndent(errorResult(ERROR_EMPTY_INPUT, "Empty request.", input), "", "  ")
+		writeResponse(w, r, http.StatusBadRequest, res)
+		return
+	}
+
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" || !epcText(name, EPC_MAX_NAME_LENGTH) {
+		writeError(w, r, http.StatusBadRequest, ERROR_INVALID_REQUEST, "Expected a single line name with at most "+strconv.Itoa(EPC_MAX_NAME_LENGTH)+" characters.")
+		return
+	}
+
+	bic := strings.ToUpper(strings.TrimSpace(r.FormValue("bic")))
+	if bic != "" && !bicPattern.MatchString(bic) {
+		writeError(w, r, http.StatusBadRequest, ERROR_INVALID_BIC, "Expected a BIC with 8 or 11 characters.")
+		return
+	}
+
+	amount, ok := epcAmount(strings.TrimSpace(r.FormValue("amount")))
+	if !ok {
+		writeError(w, r, http.StatusBadRequest, ERROR_INVALID_REQUEST, "Expected an amount between 0.01 and 999999999.99.")
+		return
+	}
+
+	remittance := strings.TrimSpace(r.FormValue("remittance"))
+	if !epcText(remittance, EPC_MAX_REMITTANCE_LENGTH) {
+		writeError(w, r, http.StatusBadRequest, ERROR_INVALID_REQUEST, "Expected a single line remittance with at most "+strconv.Itoa(EPC_MAX_REMITTANCE_LENGTH)+" characters.")
+		return
+	}
+
+	strRes, err := validate(r.Context(), iban, map[string]bool{})
+	if err != nil {
+		log.Printf("Error while validating %v: %v", m.SafeIban(iban), err)
+		result, status := lookupError(err, input)
+		res, _ := json.MarshalIndent(result, "", "  ")
+		writeResponse(w, r, status, res)
+		return
+	}
+
+	// invalid IBANs are answered with their validation result
+	var result ValidationResponse
+	if json.Unmarshal([]byte(strRes), &result) != nil || result.ValidationResult == nil || !result.Valid {
+		writeResponse(w, r, http.StatusBadRequest, []byte(strRes))
+		return
+	}
+
+	payload := epcPayload(bic, name, iban, amount, remittance)
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Length", strconv.Itoa(len(payload)))
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(payload))
+}
+
+// Reports whether text fits into a single line of the payload
+func epcText(text string, maxLength int) bool {
+	return utf8.RuneCountInString(text) <= maxLength && !strings.ContainsAny(text, "\r\n")
+}
+
+// Returns the amount in the EPC format (EUR12.30), "" for an empty amount.
+// Reports false for amounts which are malformed or out of range.
+func epcAmount(amount string) (string, bool) {
+	if amount == "" {
+		return "", true
+	}
+
+	if !amountPattern.MatchString(amount) {
+		return "", false
+	}
+
+	parts := strings.SplitN(amount, ".", 2)
+	units := strings.TrimLeft(parts[0], "0")
+	if units == "" {
+		units = "0"
+	}
+
+	cents := "00"
+	if len(parts) == 2 {
+		cents = (parts[1] + "0")[:2]
+	}
+
+	if units == "0" && cents == "00" {
+		return "", false
+	}
+
+	return "EUR" + units + "." + cents, true
+}
+
+// Builds the EPC069-12 payload of a SEPA credit transfer. Version 002 is
+// used, it makes the BIC optional. Trailing empty lines are omitted.
+func epcPayload(bic string, name string, iban string, amount string, remittance string) string {
+	lines := []string{
+		"BCD",
+		"002",
+		"1", // UTF-8
+		"SCT",
+		bic,
+		name,
+		iban,
+		amount,
+		"", // purpose
+		"", // structured remittance reference
+		remittance,
+	}
+
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestEpcAmount(t *testing.T) {
+	cases := map[string]string{
+		"":             "",
+		"12":           "EUR12.00",
+		"12.3":         "EUR12.30",
+		"0012.34":      "EUR12.34",
+		"0.01":         "EUR0.01",
+		"999999999.99": "EUR999999999.99",
+	}
+
+	for amount, expected := range cases {
+		if result, ok := epcAmount(amount); !ok || result != expected {
+			t.Errorf("expected %v for %q, got %v", expected, amount, result)
+		}
+	}
+
+	for _, amount := range []string{"0", "0.00", "1.234", "-1", "1,50", "1000000000"} {
+		if _, ok := epcAmount(amount); ok {
+			t.Errorf("expected %q to be rejected", amount)
+		}
+	}
+}
+
+func TestEpcQRPayload(t *testing.T) {
+	params := url.Values{
+		"iban":       {"DE89 3704 0044 0532 0130 00"},
+		"name":       {"Max Mustermann"},
+		"amount":     {"12.3"},
+		"remittance": {"Invoice 42"},
+	}
+
+	resp, err := http.Get(server.URL + "/epc-qr?" + params.Encode())
+	if err != nil {
+		t.Errorf("failed to get the payload %v", err)
+		t.FailNow()
+	}
+
+	data, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	expected := "BCD\n002\n1\nSCT\n\nMax Mustermann\nDE89370400440532013000\nEUR12.30\n\n\nInvoice 42"
+	if resp.StatusCode != http.StatusOK || string(data) != expected {
+		t.Errorf("unexpected payload (%v) %q", resp.StatusCode, data)
+	}
+}
+
+func TestEpcQRRejectsInvalidIban(t *testing.T) {
+	resp, err := http.Get(server.URL + "/epc-qr?iban=DE89370400440532013001&name=Max")
+	if err != nil {
+		t.Errorf("failed to get the payload %v", err)
+		t.FailNow()
+	}
+
+	var result ValidationResponse
+	json.NewDecoder(resp.Body).Decode(&result)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest || result.Valid || result.ErrorCode != ERROR_BAD_CHECKSUM {
+		t.Errorf("expected the IBAN to be rejected, got %v %v", resp.StatusCode, result.ErrorCode)
+	}
+}
+
+func TestEpcQRRequiresName(t *testing.T) {
+	resp, err := http.Get(server.URL + "/epc-qr?iban=DE89370400440532013000")
+	if err != nil {
+		t.Errorf("failed to get the payload %v", err)
+		t.FailNow()
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected HTTP 400, got %v", resp.StatusCode)
+	}
+}
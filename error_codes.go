@@ -0,0 +1,63 @@
+package main
+
+import (
+	"github.com/fourcube/goiban"
+)
+
+// Machine-readable error codes, returned in the errorCode field of error
+// responses and invalid validation results. The values are stable, clients
+// may branch on them, the messages are meant for display only.
+const (
+	ERROR_EMPTY_INPUT         = "EMPTY_INPUT"
+	ERROR_INPUT_TOO_LONG      = "INPUT_TOO_LONG"
+	ERROR_NOT_PARSEABLE       = "NOT_PARSEABLE"
+	ERROR_IBAN_TOO_LONG       = "IBAN_TOO_LONG"
+	ERROR_COUNTRY_UNSUPPORTED = "COUNTRY_NOT_SUPPORTED"
+	ERROR_COUNTRY_MISMATCH    = "COUNTRY_MISMATCH"
+	ERROR_ACCOUNT_LENGTH      = "ACCOUNT_NUMBER_LENGTH"
+	ERROR_BAD_LENGTH          = "BAD_LENGTH"
+	ERROR_STRUCTURE_MISMATCH  = "STRUCTURE_MISMATCH"
+	ERROR_BAD_CHECKSUM        = "BAD_CHECKSUM"
+	ERROR_BANK_CODE_NOT_FOUND = "BANK_CODE_NOT_FOUND"
+	ERROR_INVALID_IBAN        = "INVALID_IBAN"
+	ERROR_DB_UNAVAILABLE      = "DB_UNAVAILABLE"
+	ERROR_INVALID_REQUEST     = "INVALID_REQUEST"
+	ERROR_TOO_MANY_IBANS      = "TOO_MANY_IBANS"
+	ERROR_BODY_TOO_LARGE      = "BODY_TOO_LARGE"
+	ERROR_UNKNOWN_COUNTRY     = "UNKNOWN_COUNTRY"
+	ERROR_INVALID_BIC         = "INVALID_BIC"
+	ERROR_BIC_NOT_FOUND       = "BIC_NOT_FOUND"
+	ERROR_CALCULATION_FAILED  = "CALCULATION_FAILED"
+	ERROR_UNAUTHORIZED        = "UNAUTHORIZED"
+	ERROR_NOT_FOUND           = "NOT_FOUND"
+	ERROR_DB_TIMEOUT          = "DB_TIMEOUT"
+	ERROR_DB_BUSY             = "DB_BUSY"
+	ERROR_DB_SCHEMA           = "DB_SCHEMA_ERROR"
+	ERROR_MAINTENANCE         = "MAINTENANCE"
+)
+
+// Creates an invalid validation result carrying an error code
+func errorResult(code string, message string, iban string) *ValidationResponse {
+	return &ValidationResponse{
+		ValidationResult: goiban.NewValidationResult(false, message, iban),
+		ErrorCode:        code,
+	}
+}
+
+// Returns the error code of a result which passed the structure and
+// length checks, or "" if it is valid
+func validationErrorCode(result *goiban.ValidationResult) string {
+	if result.Valid {
+		return ""
+	}
+
+	if ibanChecksum(result.Iban) != 1 {
+		return ERROR_BAD_CHECKSUM
+	}
+
+	if valid, ok := result.CheckResults["bankCode"]; ok && !valid {
+		return ERROR_BANK_CODE_NOT_FOUND
+	}
+
+	return ERROR_INVALID_IBAN
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/fourcube/goiban"
+)
+
+// countryCodePattern matches ISO 3166 alpha-2 country codes
+var countryCodePattern = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// Reads the expectedCountry parameter
+func expectedCountryParam(r *http.Request) (string, bool) {
+	return parseExpectedCountry(r.FormValue("expectedCountry"))
+}
+
+// Returns the upper case expected country. Returns false when it is set
+// but isn't a two letter country code.
+func parseExpectedCountry(value string) (string, bool) {
+	expected := strings.ToUpper(value)
+	if expected != "" && !countryCodePattern.MatchString(expected) {
+		return "", false
+	}
+
+	return expected, true
+}
+
+// Asserts that the IBAN of the rendered validation result strRes belongs to
+// the expected country. On a mismatch the result becomes invalid, the
+// validity of the IBAN itself is kept in structureValid.
+func checkExpectedCountry(strRes string, expected string) string {
+	if expected == "" {
+		return strRes
+	}
+
+	var result ValidationResponse
+	json.Unmarshal([]byte(strRes), &result)
+
+	iban := normalizeIban(result.Iban)
+	if !goiban.IsParseable(iban).Valid {
+		return strRes
+	}
+
+	structureValid := result.Valid
+	result.StructureValid = &structureValid
+
+	countryCode := goiban.ExtractCountryCode(iban)
+	if countryCode != expected {
+		result.Valid = false
+		result.Messages = append(result.Messages, fmt.Sprintf("Expected an IBAN from %v, got %v.", expected, countryCode))
+
+		if result.ErrorCode == "" {
+			result.ErrorCode = ERROR_COUNTRY_MISMATCH
+		}
+	}
+
+	res, _ := json.MarshalIndent(result, "", "  ")
+	return string(res)
+}
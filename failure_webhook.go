@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// FAILURE_WEBHOOK_BUCKETS is the number of buckets the sliding window
+	// of every client is divided into
+	FAILURE_WEBHOOK_BUCKETS = 10
+
+	FAILURE_WEBHOOK_TIMEOUT = 5 * time.Second
+)
+
+// CLIENT_IP is the context key of the IP address of the client of a request
+const CLIENT_IP contextKey = "clientIP"
+
+// failures notifies the failure webhook, nil when it is disabled
+var failures *failureWebhook
+
+// FailureAlert is the payload posted to the failure webhook
+type FailureAlert struct {
+	Client      string    `json:"client"`
+	Invalid     int       `json:"invalid"`
+	Total       int       `json:"total"`
+	Rate        float64   `json:"rate"`
+	Window      string    `json:"window"`
+	WindowStart time.Time `json:"windowStart"`
+	WindowEnd   time.Time `json:"windowEnd"`
+}
+
+// failureWebhook counts the valid and invalid results of every client over
+// a sliding window and posts a FailureAlert to url when the share of
+// invalid results reaches threshold percent of at least minimum
+// results. A client is notified at most once per window.
+type failureWebhook struct {
+	url       string
+	threshold int
+	minimum   int
+	window    time.Duration
+	client    *http.Client
+
+	mu      sync.Mutex
+	clients map[string]*failureWindow
+}
+
+type failureWindow struct {
+	buckets  [FAILURE_WEBHOOK_BUCKETS]failureBucket
+	notified time.Time
+}
+
+type failureBucket struct {
+	start          time.Time
+	total, invalid int
+}
+
+// Returns the failure webhook posting to url, nil when url is empty.
+// Clients without results in the last window are removed every window.
+func newFailureWebhook(url string, threshold int, minimum int, window time.Duration) *failureWebhook {
+	if url == "" || window <= 0 {
+		return nil
+	}
+
+	fw := &failureWebhook{
+		url:       url,
+		threshold: threshold,
+		minimum:   minimum,
+		window:    window,
+		client:    &http.Client{Timeout: FAILURE_WEBHOOK_TIMEOUT},
+		clients:   map[string]*failureWindow{},
+	}
+
+	go func() {
+		for range time.Tick(window) {
+			fw.deleteIdle(time.Now())
+		}
+	}()
+
+	return fw
+}
+
+// Counts a validation result of the client of ctx and notifies the webhook
+// in the background when the client exceeds the threshold. Results which
+// can't be attributed to a client are not counted.
+func (fw *failureWebhook) record(ctx context.Context, valid bool) {
+	if fw == nil {
+		return
+	}
+
+	client := failureClient(ctx)
+	if client == "" {
+		return
+	}
+
+	if alert := fw.count(client, valid, time.Now()); alert != nil {
+		go fw.notify(alert)
+	}
+}
+
+// Counts a result at now and returns the alert to send, if any
+func (fw *failureWebhook) count(client string, valid bool, now time.Time) *FailureAlert {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	w, ok := fw.clients[client]
+	if !ok {
+		w = &failureWindow{}
+		fw.clients[client] = w
+	}
+
+	width := fw.window / FAILURE_WEBHOOK_BUCKETS
+	start := now.Truncate(width)
+	bucket := &w.buckets[(start.UnixNano()/int64(width))%FAILURE_WEBHOOK_BUCKETS]
+	if !bucket.start.Equal(start) {
+		*bucket = failureBucket{start: start}
+	}
+
+	bucket.total++
+	if !valid {
+		bucket.invalid++
+	}
+
+	alert := &FailureAlert{Client: client, Window: fw.window.String(), WindowStart: start, WindowEnd: now}
+	for _, b := range w.buckets {
+		if b.total == 0 || !now.Before(b.start.Add(fw.window)) {
+			continue
+		}
+
+		alert.Total += b.total
+		alert.Invalid += b.invalid
+		if b.start.Before(alert.WindowStart) {
+			alert.WindowStart = b.start
+		}
+	}
+
+	if alert.Total < fw.minimum || alert.Invalid*100 < fw.threshold*alert.Total {
+		return nil
+	}
+
+	if !w.notified.IsZero() && now.Before(w.notified.Add(fw.window)) {
+		return nil
+	}
+
+	w.notified = now
+	alert.Rate = float64(alert.Invalid) / float64(alert.Total)
+	return alert
+}
+
+// Posts the alert to the webhook, failures are logged
+func (fw *failureWebhook) notify(alert *FailureAlert) {
+	body, _ := json.Marshal(alert)
+
+	resp, err := fw.client.Post(fw.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Printf("Error sending the failure webhook for %v: %v", alert.Client, err)
+		return
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		log.Printf("Error sending the failure webhook for %v: status %v", alert.Client, resp.StatusCode)
+	}
+}
+
+func (fw *failureWebhook) deleteIdle(now time.Time) {
+	fw.mu.Lock()
+	defer fw.mu.Unlock()
+
+	for client, w := range fw.clients {
+		idle := true
+		for _, b := range w.buckets {
+			if b.total > 0 && now.Before(b.start.Add(fw.window)) {
+				idle = false
+				break
+			}
+		}
+
+		if idle {
+			delete(fw.clients, client)
+		}
+	}
+}
+
+// Returns the client of a validation: the label of its API key or, for
+// unlabeled keys and without authentication, the IP address of the client
+func failureClient(ctx context.Context) string {
+	if label, _ := ctx.Value(API_KEY_LABEL).(string); label != "" {
+		return label
+	}
+
+	ip, _ := ctx.Value(CLIENT_IP).(string)
+	return ip
+}
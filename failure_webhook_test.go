@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFailureWebhookThreshold(t *testing.T) {
+	fw := &failureWebhook{threshold: 50, minimum: 4, window: time.Minute, clients: map[string]*failureWindow{}}
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for i, valid := range []bool{true, false, false} {
+		if alert := fw.count("partner", valid, now.Add(time.Duration(i)*time.Second)); alert != nil {
+			t.Errorf("expected no alert below the minimum, got %+v", alert)
+		}
+	}
+
+	alert := fw.count("partner", true, now.Add(3*time.Second))
+	if alert == nil || alert.Total != 4 || alert.Invalid != 2 || alert.Rate != 0.5 {
+		t.Errorf("expected an alert at 2 of 4 invalid results, got %+v", alert)
+		t.FailNow()
+	}
+
+	if !alert.WindowStart.Equal(now) || alert.Window != "1m0s" {
+		t.Errorf("unexpected window of %+v", alert)
+	}
+
+	if alert := fw.count("partner", false, now.Add(4*time.Second)); alert != nil {
+		t.Errorf("expected a single alert per window, got %+v", alert)
+	}
+
+	if alert := fw.count("other", false, now); alert != nil {
+		t.Errorf("expected the clients to be counted separately, got %+v", alert)
+	}
+
+	// the results have left the window
+	later := now.Add(2 * time.Minute)
+	if alert := fw.count("partner", false, later); alert != nil {
+		t.Errorf("expected the old results to be dropped, got %+v", alert)
+	}
+
+	fw.deleteIdle(later.Add(2 * time.Minute))
+	if len(fw.clients) != 0 {
+		t.Errorf("expected idle clients to be removed, got %v", len(fw.clients))
+	}
+}
+
+func TestFailureWebhookNotifies(t *testing.T) {
+	alerts := make(chan FailureAlert, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert FailureAlert
+		json.NewDecoder(r.Body).Decode(&alert)
+		alerts <- alert
+	}))
+	defer hook.Close()
+
+	defer func(fw *failureWebhook) { failures = fw }(failures)
+	failures = newFailureWebhook(hook.URL, 100, 2, time.Minute)
+
+	ctx := context.WithValue(context.Background(), API_KEY_LABEL, "partner")
+	for _, iban := range []string{"DE89370400440532013001", "DE89370400440532013002"} {
+		_, err := validate(ctx, iban, map[string]bool{})
+		if err != nil {
+			t.Errorf("failed to validate %v", err)
+			t.FailNow()
+		}
+	}
+
+	select {
+	case alert := <-alerts:
+		if alert.Client != "partner" || alert.Total != 2 || alert.Invalid != 2 {
+			t.Errorf("unexpected alert %+v", alert)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("expected the webhook to be notified")
+	}
+}
+
+func TestNoFailureWebhookByDefault(t *testing.T) {
+	if newFailureWebhook("", 50, 20, time.Minute) != nil {
+		t.Errorf("expected the webhook to be disabled without a URL")
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Applies the fields and exclude parameters to the JSON response data.
+// fields=valid,bankCode keeps only the listed fields, exclude=messages
+// drops the listed fields. A plain name matches the field at any depth
+// (bankCode is found in bankData), a dotted path like bankData.bic only
+// matches there. Arrays of results are filtered entry by entry. The data
+// is returned unchanged when neither parameter is given.
+func selectFields(data []byte, r *http.Request) []byte {
+	fields := fieldList(r.URL.Query().Get("fields"))
+	exclude := fieldList(r.URL.Query().Get("exclude"))
+	if len(fields) == 0 && len(exclude) == 0 {
+		return data
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return data
+	}
+
+	if len(fields) > 0 {
+		value, _ = includeFields(value, "", fields)
+	}
+	if len(exclude) > 0 {
+		value = excludeFields(value, "", exclude)
+	}
+
+	selected, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return data
+	}
+
+	return selected
+}
+
+// Splits a comma-separated list of field names into a set
+func fieldList(value string) map[string]bool {
+	list := map[string]bool{}
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			list[name] = true
+		}
+	}
+
+	return list
+}
+
+// Reports whether the field with the key at path is listed
+func fieldListed(list map[string]bool, path string, key string) bool {
+	return list[key] || list[path]
+}
+
+// Keeps the listed fields of value and the objects leading to them.
+// Returns false when nothing in value is listed.
+func includeFields(value interface{}, prefix string, fields map[string]bool) (interface{}, bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		included := map[string]interface{}{}
+		for key, child := range v {
+			path := prefix + key
+			if fieldListed(fields, path, key) {
+				included[key] = child
+			} else if selected, ok := includeFields(child, path+".", fields); ok {
+				included[key] = selected
+			}
+		}
+
+		return included, len(included) > 0
+	case []interface{}:
+		entries := make([]interface{}, len(v))
+		found := false
+		for i, child := range v {
+			var ok bool
+			entries[i], ok = includeFields(child, prefix, fields)
+			found = found || ok
+		}
+
+		return entries, found
+	}
+
+	return nil, false
+}
+
+// Drops the listed fields from value
+func excludeFields(value interface{}, prefix string, exclude map[string]bool) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			path := prefix + key
+			if fieldListed(exclude, path, key) {
+				delete(v, key)
+			} else {
+				v[key] = excludeFields(child, path+".", exclude)
+			}
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = excludeFields(child, prefix, exclude)
+		}
+	}
+
+	return value
+}
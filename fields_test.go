@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSelectFields(t *testing.T) {
+	data := []byte(`{"valid": true, "messages": ["Valid"], "iban": "DE89370400440532013000", "bankData": {"bankCode": "37040044", "name": "Commerzbank", "bic": "COBADEFFXXX"}, "checkResults": {"length": true}}`)
+
+	cases := map[string]string{
+		"fields=valid,bankCode":             `{"bankData":{"bankCode":"37040044"},"valid":true}`,
+		"fields=iban,bankData.bic":          `{"bankData":{"bic":"COBADEFFXXX"},"iban":"DE89370400440532013000"}`,
+		"exclude=messages,bankData,length":  `{"checkResults":{},"iban":"DE89370400440532013000","valid":true}`,
+		"fields=valid,bankData&exclude=bic": `{"bankData":{"bankCode":"37040044","name":"Commerzbank"},"valid":true}`,
+	}
+
+	for query, expected := range cases {
+		selected := selectFields(data, httptest.NewRequest("GET", "/validate/DE89370400440532013000?"+query, nil))
+
+		var compact bytes.Buffer
+		json.Compact(&compact, selected)
+		if compact.String() != expected {
+			t.Errorf("expected %v for %v, got %v", expected, query, compact.String())
+		}
+	}
+
+	if !bytes.Equal(selectFields(data, httptest.NewRequest("GET", "/validate/DE89370400440532013000", nil)), data) {
+		t.Errorf("expected the data to be unchanged without fields")
+	}
+}
+
+func TestSelectFieldsOfBatch(t *testing.T) {
+	body, _ := json.Marshal([]string{"DE89370400440532013000", "XX"})
+
+	resp := httptest.NewRecorder()
+	batchValidationHandler(resp, httptest.NewRequest("POST", "/validate/batch?fields=valid", bytes.NewReader(body)), nil)
+
+	var results []map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&results)
+
+	if len(results) != 2 || len(results[0]) != 1 || len(results[1]) != 1 || results[0]["valid"] != true {
+		t.Errorf("expected only the validity of every entry, got %v", results)
+	}
+}
+
+func TestSelectFieldsOfValidation(t *testing.T) {
+	strRes, _ := validate(context.Background(), "DE89370400440532013000", map[string]bool{})
+
+	resp := httptest.NewRecorder()
+	writeResponse(resp, httptest.NewRequest("GET", "/validate/DE89370400440532013000?fields=valid,iban", nil), http.StatusOK, []byte(strRes))
+
+	var result map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&result)
+
+	if len(result) != 2 || result["iban"] != "DE89370400440532013000" {
+		t.Errorf("expected only the validity and the IBAN, got %v", result)
+	}
+}
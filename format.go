@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Supported response formats
+const (
+	FORMAT_JSON = "json"
+	FORMAT_XML  = "xml"
+
+	// FORMAT_NDJSON is only supported by /validate/batch
+	FORMAT_NDJSON = "ndjson"
+
+	// FORMAT_TEXT returns only the validity of results as plain text
+	FORMAT_TEXT = "text"
+)
+
+var contentTypes = map[string]string{
+	FORMAT_JSON: "application/json; charset=utf-8",
+	FORMAT_XML:  "application/xml; charset=utf-8",
+
+	FORMAT_NDJSON: "application/x-ndjson",
+	FORMAT_TEXT:   "text/plain; charset=utf-8",
+}
+
+var xmlName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// Returns the response format requested by the client. The format query
+// parameter takes precedence over the Accept header, JSON is the default.
+func responseFormat(r *http.Request) string {
+	switch r.URL.Query().Get("format") {
+	case FORMAT_XML:
+		return FORMAT_XML
+	case FORMAT_JSON:
+		return FORMAT_JSON
+	case FORMAT_TEXT:
+		return FORMAT_TEXT
+	}
+
+	accept := r.Header.Get("Accept")
+	if strings.Contains(accept, "application/xml") || strings.Contains(accept, "text/xml") {
+		return FORMAT_XML
+	}
+
+	return FORMAT_JSON
+}
+
+// Writes the JSON response data in the format requested by the client.
+// Standard messages are translated to the language of the client, the
+// fields and exclude parameters select the fields of the response. The
+// status is the same in all formats.
+func writeResponse(w http.ResponseWriter, r *http.Request, status int, data []byte) {
+	format := responseFormat(r)
+
+	if format == FORMAT_TEXT {
+		text, ok := validityText(data)
+		if ok {
+			w.Header().Set("Content-Type", contentTypes[FORMAT_TEXT])
+			w.Header().Set("Content-Length", strconv.Itoa(len(text)))
+			w.WriteHeader(status)
+			w.Write(text)
+			return
+		}
+
+		// responses without a validity are written as JSON
+		format = FORMAT_JSON
+	}
+
+	language := messageLanguage(r)
+	data = localize(data, language)
+	data = selectFields(data, r)
+
+	if format == FORMAT_XML {
+		converted, err := jsonToXML(data)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		data = converted
+	}
+
+	w.Header().Set("Content-Type", contentTypes[format])
+	w.Header().Set("Content-Language", language)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(status)
+	w.Write(data)
+}
+
+// Returns the valid field of a JSON result as "true" or "false", or one
+// line per result for an array of results, e.g. of a comma-separated list.
+// Returns false when the data has no valid field.
+func validityText(data []byte) ([]byte, bool) {
+	var results []struct{ Valid *bool }
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		if json.Unmarshal(data, &results) != nil || len(results) == 0 {
+			return nil, false
+		}
+	} else {
+		results = make([]struct{ Valid *bool }, 1)
+		if json.Unmarshal(data, &results[0]) != nil {
+			return nil, false
+		}
+	}
+
+	lines := make([]string, len(results))
+	for i, result := range results {
+		if result.Valid == nil {
+			return nil, false
+		}
+		lines[i] = strconv.FormatBool(*result.Valid)
+	}
+
+	return []byte(strings.Join(lines, "\n")), true
+}
+
+// Converts a JSON document to XML. Objects become elements named after
+// their keys, array entries become <item> elements. Keys that are no valid
+// XML names are written as <entry key="...">.
+func jsonToXML(data []byte) ([]byte, error) {
+	var value interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err := decoder.Decode(&value)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+
+	err = encodeXML(encoder, "response", value)
+	if err != nil {
+		return nil, err
+	}
+
+	err = encoder.Flush()
+	return buf.Bytes(), err
+}
+
+func encodeXML(encoder *xml.Encoder, name string, value interface{}) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if !xmlName.MatchString(name) {
+		start = xml.StartElement{
+			Name: xml.Name{Local: "entry"},
+			Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: name}},
+		}
+	}
+
+	err := encoder.EncodeToken(start)
+	if err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			err = encodeXML(encoder, key, v[key])
+			if err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			err = encodeXML(encoder, "item", item)
+			if err != nil {
+				return err
+			}
+		}
+	case nil:
+	case string:
+		err = encoder.EncodeToken(xml.CharData(v))
+	case bool:
+		err = encoder.EncodeToken(xml.CharData(strconv.FormatBool(v)))
+	case json.Number:
+		err = encoder.EncodeToken(xml.CharData(v.String()))
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return encoder.EncodeToken(start.End())
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+// SELECT_CHECK_METHOD reads the check method of the account numbers of a
+// German bank, the "Prüfzifferberechnungsmethode" of the bank code file of
+// the Bundesbank, e.g. "00". Not every dataset contains the column.
+const SELECT_CHECK_METHOD = "SELECT check_method FROM BANK_DATA WHERE bankcode = ? AND country = 'DE' LIMIT 1"
+
+// checkMethodColumn tells whether the dataset contains the check_method column
+var checkMethodColumn = &optionalColumns{probe: "SELECT check_method FROM BANK_DATA LIMIT 1"}
+
+// Weights of the check methods, from the digit left of the check digit
+// to the left
+var (
+	weights21    = []int{2, 1, 2, 1, 2, 1, 2, 1, 2}
+	weights371   = []int{3, 7, 1, 3, 7, 1, 3, 7, 1}
+	weights731   = []int{7, 3, 1, 7, 3, 1, 7, 3, 1}
+	weights2to7  = []int{2, 3, 4, 5, 6, 7, 2, 3, 4}
+	weights2to9  = []int{2, 3, 4, 5, 6, 7, 8, 9, 2}
+	weights2to10 = []int{2, 3, 4, 5, 6, 7, 8, 9, 10}
+)
+
+// germanCheckMethods are the implemented check methods of the Bundesbank,
+// called with the 10 digit account number. The check digit is the last
+// digit of all of them.
+var germanCheckMethods = map[string]func(account string) bool{
+	"00": func(account string) bool { return mod10CheckDigit(account, weights21, true) },
+	"01": func(account string) bool { return mod10CheckDigit(account, weights371, false) },
+	"02": func(account string) bool { return mod11CheckDigit(account, weights2to9, -1) },
+	"03": func(account string) bool { return mod10CheckDigit(account, weights21, false) },
+	"04": func(account string) bool { return mod11CheckDigit(account, weights2to7, -1) },
+	"05": func(account string) bool { return mod10CheckDigit(account, weights731, false) },
+	"06": func(account string) bool { return mod11CheckDigit(account, weights2to7, 0) },
+	"07": func(account string) bool { return mod11CheckDigit(account, weights2to10, -1) },
+	"08": func(account string) bool { return mod10CheckDigit(account, weights21, true) },
+	"10": func(account string) bool { return mod11CheckDigit(account, weights2to10, 0) },
+	"11": func(account string) bool { return mod11CheckDigit(account, weights2to10, 9) },
+}
+
+// Validates the check digit of the account number of a German IBAN with the
+// check method of its bank. Returns STATUS_UNSUPPORTED when the dataset has
+// no method for the bank or the method isn't implemented. While the
+// circuit breaker is open the lookup fails right away.
+func germanNationalChecksum(ctx context.Context, iban string) (string, error) {
+	if len(iban) != 22 || !isDigits(iban[4:]) {
+		return STATUS_INVALID, nil
+	}
+
+	if checkMethodColumn.missing() {
+		return STATUS_UNSUPPORTED, nil
+	}
+
+	err := dbBreaker.allow()
+	if err != nil {
+		return "", err
+	}
+
+	method, err := lookupCheckMethod(ctx, iban[4:12])
+	dbBreaker.record(err)
+	if err != nil {
+		return "", err
+	}
+
+	return germanCheckDigit(method, iban[12:]), nil
+}
+
+// Validates the 10 digit account number with the check method. Method 08
+// only checks account numbers from 60000.
+func germanCheckDigit(method string, account string) string {
+	check, ok := germanCheckMethods[method]
+	if !ok || (method == "08" && account < "0000060000") {
+		return STATUS_UNSUPPORTED
+	}
+
+	if check(account) {
+		return STATUS_VALID
+	}
+	return STATUS_INVALID
+}
+
+func lookupCheckMethod(ctx context.Context, bankCode string) (string, error) {
+	slot, err := acquireDBSlot()
+	if err != nil {
+		return "", err
+	}
+	defer slot.release()
+
+	exist, err := checkMethodColumn.exist(ctx)
+	if err != nil || !exist {
+		return "", err
+	}
+
+	var method sql.NullString
+	err = withDBRetry(func() error {
+		ctx, cancel := lookupContext(ctx)
+		defer cancel()
+
+		return db.QueryRowContext(ctx, SELECT_CHECK_METHOD, bankCode).Scan(&method)
+	})
+
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if isUndefinedColumnError(err) {
+		checkMethodColumn.setMissing()
+		return "", nil
+	}
+	if err == context.DeadlineExceeded {
+		return "", errDBTimeout
+	}
+	if err != nil {
+		return "", schemaError(err)
+	}
+
+	return strings.ToUpper(strings.TrimSpace(method.String)), nil
+}
+
+// The products of the digits and weights are summed up, with crossSum the
+// digit sums of the products. The check digit completes the sum to a
+// multiple of 10.
+func mod10CheckDigit(account string, weights []int, crossSum bool) bool {
+	sum := 0
+	for i, weight := range weights {
+		product := int(account[8-i]-'0') * weight
+		if crossSum {
+			product = product/10 + product%10
+		}
+		sum += product
+	}
+
+	return (10-sum%10)%10 == int(account[9]-'0')
+}
+
+// The check digit is 11 minus the remainder of the weighted sum divided by
+// 11, 0 for a remainder of 0. A remainder of 1 gives remainder1 as check
+// digit, account numbers with it are invalid when remainder1 is negative.
+func mod11CheckDigit(account string, weights []int, remainder1 int) bool {
+	sum := 0
+	for i, weight := range weights {
+		sum += int(account[8-i]-'0') * weight
+	}
+
+	expected := 11 - sum%11
+	switch sum % 11 {
+	case 0:
+		expected = 0
+	case 1:
+		if remainder1 < 0 {
+			return false
+		}
+		expected = remainder1
+	}
+
+	return expected == int(account[9]-'0')
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGermanCheckDigit(t *testing.T) {
+	cases := []struct {
+		method, account, expected string
+	}{
+		{"00", "0009290701", STATUS_VALID},
+		{"00", "0539290858", STATUS_VALID},
+		{"00", "0009290702", STATUS_INVALID},
+		{"01", "1234567899", STATUS_VALID},
+		{"02", "1234567897", STATUS_VALID},
+		{"03", "1234567890", STATUS_VALID},
+		{"04", "1234567892", STATUS_VALID},
+		{"05", "1234567897", STATUS_VALID},
+		{"06", "0094012341", STATUS_VALID},
+		{"06", "5073321010", STATUS_VALID},
+		{"06", "0094012342", STATUS_INVALID},
+		{"07", "9876543210", STATUS_VALID},
+		{"07", "1000000019", STATUS_INVALID},
+		{"08", "1234567897", STATUS_VALID},
+		{"08", "0000012345", STATUS_UNSUPPORTED},
+		{"10", "0012345008", STATUS_VALID},
+		{"10", "0087654008", STATUS_VALID},
+		{"11", "1234567899", STATUS_VALID},
+		{"11", "1000000019", STATUS_VALID},
+		{"13", "0532013000", STATUS_UNSUPPORTED},
+		{"", "0532013000", STATUS_UNSUPPORTED},
+	}
+
+	for _, c := range cases {
+		if status := germanCheckDigit(c.method, c.account); status != c.expected {
+			t.Errorf("expected %v for %v with method %v, got %v", c.expected, c.account, c.method, status)
+		}
+	}
+}
+
+func TestGermanNationalChecksumLookup(t *testing.T) {
+	defer func(cb *circuitBreaker) {
+		dbBreaker = cb
+		atomic.StoreInt32(&checkMethodColumn.state, COLUMNS_UNKNOWN)
+	}(dbBreaker)
+
+	dbBreaker = newCircuitBreaker(1, time.Hour)
+	dbBreaker.allow()
+	dbBreaker.record(errDBTimeout)
+
+	// the lookup goes through the circuit breaker
+	if _, err := germanNationalChecksum(context.Background(), "DE89370400440532013000"); err != errCircuitOpen {
+		t.Errorf("expected the open breaker to fail the lookup, got %v", err)
+	}
+
+	// datasets known to lack the column aren't queried at all
+	checkMethodColumn.setMissing()
+	if status, err := germanNationalChecksum(context.Background(), "DE89370400440532013000"); err != nil || status != STATUS_UNSUPPORTED {
+		t.Errorf("expected %v without the column, got %v %v", STATUS_UNSUPPORTED, status, err)
+	}
+}
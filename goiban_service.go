@@ -1,21 +1,30 @@
 package main
 
 import (
+	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"syscall"
 	"time"
+	"unicode"
 
 	"github.com/fourcube/goiban"
 	m "github.com/fourcube/goiban-service/metrics"
-	_ "github.com/go-sql-driver/mysql"
 	"github.com/julienschmidt/httprouter"
-	"github.com/pmylund/go-cache"
 	"github.com/rs/cors"
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/grpc"
 )
 
 /**
@@ -25,157 +34,919 @@ route							description
 --------------------			--------------------------------------------------------
 /validate/{iban} 				Tries to validate {iban} and returns a HTTP response
 								in JSON. See goiban.ValidationResult for details of the
-								data returned.
+								data returned. A comma-separated list of up to 10 IBANs
+								returns an array of results.
+								validateNationalChecksum=true additionally checks the
+								national check digits of the account number.
+								German accounts are checked with the method of their bank
+								in the bank data. Accounts without a check are reported
+								as unsupported.
+								expectedCountry={countryCode} fails the validation of
+								IBANs from other countries. includeBankAddress=true adds
+								the zip code and city to the bank data of getBIC=true.
+								requireBIC=true fails the validation when getBIC=true
+								finds no BIC. accountNumberLength={n} fails the
+								validation when the account number in the BBAN doesn't
+								have n characters. suggest=true returns corrections of IBANs
+								with a wrong checksum. includeSepaReachability=true adds
+								the SCT and SDD reachability of the bank to getBIC=true.
+								Swiss and Liechtenstein results tell whether the IBAN
+								is a QR-IBAN (qrIban). includeComponents=true adds the
+								bank code, branch code and account number of the BBAN.
+								debug=true adds the version of the goiban library to
+								the result (debug) and the X-Goiban-Version header.
+								full=true turns on getBIC, validateBankCode,
+								includeBankAddress, includeSepaReachability and
+								includeComponents at once. format=text returns only
+								"true" or "false" as plain text.
 
-/*								Renders static content from the "./static" folder
+/v2/validate/{iban}				Like /validate/{iban}, but reports the structure, checksum,
+								bank code and BIC checks separately.
+
+/validate-bban/{countryCode}/{bban}
+								Computes the check digits of the BBAN for the country
+								and validates the resulting IBAN like /validate/{iban}.
+
+/checkdigits/{countryCode}/{bban}
+								Returns the check digits of the IBAN of the BBAN in the
+								country.
+
+/validate						Accepts a JSON object with the IBAN and the validation
+								options via POST, keeps the IBAN out of the URL.
+
+/validate/batch					Accepts a JSON array of IBANs via POST and returns a
+								JSON array of validation results in the same order. The
+								results are streamed as they are computed. format=ndjson
+								returns one result per line (application/x-ndjson).
+
+/validate/csv					Accepts a CSV file of IBANs via POST, raw or as multipart
+								upload, and returns the IBAN, validity, country and
+								messages of every row as CSV. column selects the column
+								of the IBANs, header=true skips the first row.
+
+/bic/{bic}						Returns the bank name, bank code and country of the bank
+								with the 8 or 11 character {bic}.
+
+/banks/{countryCode}			Lists the banks of a country, supports pagination through
+								the limit and offset parameters.
+
+/calculate/countries			Lists the country codes IBANs can be calculated for.
+
+/calculate/{countryCode}/{bankCode}/{accountNumber}
+								Calculates the IBAN, /v2/calculate/... also validates it.
+								validateOnly=true only reports whether the calculated
+								IBAN is valid without returning it.
+
+/calculate?countryCode=&bankCode=&accountNumber=
+								Like /calculate/{countryCode}/..., takes the inputs as
+								query parameters and passes them on unchanged.
+
+/format/{iban}					Returns the electronic form and the print form (groups
+								of four characters) of {iban}.
+
+/epc-qr							Returns the EPC QR code payload of a SEPA credit transfer
+								to the iban parameter. Also takes name, bic, amount and
+								remittance.
+
+/stats							Returns the number of valid and invalid validations and
+								the validations per country as JSON.
+
+/metrics/reset					Clears the in-memory metrics via POST. Not available in
+								the Live environment.
+
+/health							Reports that the service is alive.
+
+/ready							Reports whether the database can be reached. Not ready
+								in maintenance mode, when the validation and calculation
+								routes return 503.
+
+/version						Returns the version, git commit, build time and the
+								version of the goiban library.
+
+/admin/banks/import				Upserts bank records (country, bank code, name, BIC) sent
+								as JSON array or CSV via POST. Requires API keys.
+
+/*								Renders the static content embedded from the "./static"
+								folder (or from GOIBAN_STATIC_DIR)
 */
 var (
-	c            = cache.New(5*time.Minute, 30*time.Second)
+	c            Cache = newMemoryCache(DEFAULT_CACHE_TTL, DEFAULT_CACHE_CLEANUP)
 	db           *sql.DB
 	err          error
 	PREP_ERR     error
 	ENV          string
-	metrics      *m.KeenMetrics
-	inmemMetrics = m.NewInmemMetricsRegister()
+	inmemMetrics           = m.NewInmemMetricsRegister()
+	metrics      m.Metrics = inmemMetrics
+	promMetrics            = m.NewPrometheusMetrics()
+	validations  singleflight.Group
 )
 
 func main() {
-	if len(os.Args) < 3 {
-		fmt.Println("usage: goiban-service <port> <dburl> [<env>] [keenProjectID] [keenWriteAPIKey]")
+	flag.StringVar(&configPath, "config", "", "path to a JSON config file")
+	flag.Parse()
+
+	if configPath != "" {
+		err := loadConfigFile(cfg, configPath)
+		if err != nil {
+			log.Fatalf("Error reading config file: %v", err)
+		}
+	}
+
+	loadEnv(cfg)
+	loadArgs(cfg, flag.Args())
+
+	if cfg.Port == "" || cfg.DBUrl == "" {
+		fmt.Println("usage: goiban-service [--config <file>] <port> [<dburl>] [<env>] [keenProjectID] [keenWriteAPIKey]")
+		fmt.Println("       <dburl> and keenWriteAPIKey fall back to GOIBAN_DB_URL and GOIBAN_KEEN_WRITE_KEY")
+		fmt.Println("       <port> can be unix:/path/to/sock to listen on a Unix socket")
 		return
 	}
 
-	port := os.Args[1]
-	mysqlURL := os.Args[2]
+	err := checkTLSConfig(cfg)
+	if err != nil {
+		log.Fatalf("Invalid TLS configuration: %v", err)
+	}
+
+	if cfg.AccessLogFormat != ACCESS_LOG_COMBINED && cfg.AccessLogFormat != ACCESS_LOG_COMMON {
+		log.Fatalf("Unknown access log format: %v", cfg.AccessLogFormat)
+	}
+
+	trustedProxies, err = parseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		log.Fatalf("Invalid trusted proxies: %v", err)
+	}
+
+	c = newCache(cfg)
+
+	m.MaskIbans = cfg.MaskIban
 
-	if len(os.Args) < 6 {
-		ENV = "Test"
+	ENV = cfg.Env
+	if !cfg.Metrics {
+		log.Printf("Metrics are disabled")
 	} else {
-		ENV = os.Args[3]
-		metrics = &m.KeenMetrics{
-			ProjectID:   os.Args[4],
-			WriteAPIKey: os.Args[5],
+		metrics, err = newMetrics(cfg)
+		if err != nil {
+			log.Fatalf("Invalid metrics configuration: %v", err)
+		}
+
+		// surface wrong credentials at startup, not when events are missing
+		if keen, ok := metrics.(*m.KeenMetrics); ok {
+			go checkKeenCredentials(keen)
 		}
 	}
 
-	listen(port, ENV, mysqlURL)
+	listen(cfg.Port, ENV, cfg.DBUrl)
 }
 
 func listen(port string, environment string, dbUrl string) {
 	log.Printf("Setting env to %v", environment)
 
-	db, err = sql.Open("mysql", dbUrl)
+	db, err = openDB(dbUrl)
 
 	if err != nil {
 		log.Fatalf("Error opening DB connection: %v", err)
 	}
 
+	db.SetMaxOpenConns(cfg.DBMaxOpenConns)
+	db.SetMaxIdleConns(cfg.DBMaxIdleConns)
+	db.SetConnMaxLifetime(cfg.DBConnMaxLifetime.Duration)
+	limitDBConcurrency(cfg.DBMaxConcurrency)
+	dbBreaker = newCircuitBreaker(cfg.DBBreakerThreshold, cfg.DBBreakerCooldown.Duration)
+	failures = newFailureWebhook(cfg.FailureWebhookURL, cfg.FailureWebhookThreshold, cfg.FailureWebhookMinimum, cfg.FailureWebhookWindow.Duration)
+
+	err = probeSchema(context.Background())
+	if err == errDBSchema {
+		log.Printf("WARNING: the BANK_DATA table is missing or incomplete, bank code and BIC lookups fail with DB_SCHEMA_ERROR until it is created (e.g. by goiban-data-loader)")
+	} else if err != nil {
+		log.Printf("Cannot check the database schema: %v", err)
+	}
+
+	if cfg.Warmup {
+		warmup(cfg)
+	}
+
 	router := httprouter.New()
 	corsHandler := cors.New(cors.Options{
-		AllowedOrigins: []string{"*"},
-		AllowedMethods: []string{"GET"},
+		// the allowed origins can be reloaded
+		AllowOriginFunc: originAllowed,
+		AllowedMethods:  []string{"GET", "POST"},
+		AllowedHeaders:  []string{"Origin", "Accept", "Content-Type", "X-Requested-With", "X-API-Key"},
 	})
 
-	router.GET("/validate/:iban", validationHandler)
+	router.GET("/validate/:iban", unlessMaintenance(requireAPIKey(validationHandler)))
+	router.POST("/validate", unlessMaintenance(requireAPIKey(postValidationHandler)))
+	router.POST("/validate/batch", unlessMaintenance(requireAPIKey(batchValidationHandler)))
+	router.POST("/validate/csv", unlessMaintenance(requireAPIKey(csvValidationHandler)))
 	router.GET("/countries", countryCodeHandler)
-	router.GET("/calculate/:countryCode/:bankCode/:accountNumber", calculateIBAN)
-	router.GET("/v2/calculate/:countryCode/:bankCode/:accountNumber", calculateAndValidateIBAN)
-	router.Handler("GET", "/metrics", http.Handler(inmemMetrics))
+	router.GET("/bic/:bic", unlessMaintenance(requireAPIKey(bicLookupHandler)))
+	router.GET("/banks/:countryCode", unlessMaintenance(requireAPIKey(banksHandler)))
+	router.GET("/calculate", unlessMaintenance(requireAPIKey(calculateIBANFromQuery)))
+	router.GET("/calculate/:countryCode", calculationCountriesHandler)
+	router.GET("/calculate/:countryCode/:bankCode/:accountNumber", unlessMaintenance(requireAPIKey(calculateIBAN)))
+	router.GET("/v2/calculate/:countryCode/:bankCode/:accountNumber", unlessMaintenance(requireAPIKey(calculateAndValidateIBAN)))
+	router.GET("/v2/validate/:iban", unlessMaintenance(requireAPIKey(validationHandlerV2)))
+	router.GET("/validate-bban/:countryCode/:bban", unlessMaintenance(requireAPIKey(bbanValidationHandler)))
+	router.GET("/checkdigits/:countryCode/:bban", unlessMaintenance(requireAPIKey(checkDigitsHandler)))
+	router.GET("/format/:iban", unlessMaintenance(requireAPIKey(formatHandler)))
+	router.GET("/epc-qr", unlessMaintenance(requireAPIKey(epcQRHandler)))
+	if cfg.Metrics {
+		router.Handler("GET", "/metrics", http.Handler(inmemMetrics))
+		router.Handler("GET", "/metrics/prometheus", http.Handler(promMetrics))
+		router.GET("/stats", statsHandler)
 
-	//Only host the static template when the ENV is 'Live' or 'Test'
-	if environment == "Live" || environment == "Test" {
-		router.NotFound = http.FileServer(http.Dir("static"))
+		if ENV != "Live" {
+			router.POST("/metrics/reset", requireAPIKey(metricsResetHandler))
+		}
+	}
+	router.GET("/health", healthHandler)
+	router.GET("/ready", readyHandler)
+	router.GET("/version", versionHandler)
+	router.POST("/admin/banks/import", requireAPIKey(bankImportHandler))
+
+	if serveStatic(cfg, environment) {
+		router.NotFound = http.FileServer(staticFileSystem(cfg.StaticDir))
+	} else {
+		router.NotFound = http.HandlerFunc(notFoundHandler)
+	}
+
+	var routes http.Handler = router
+	if cfg.Metrics {
+		routes = httpMetricsHandler(router, router)
+	}
+
+	handler := gzipHandler(corsHandler.Handler(mountAt(cfg.BasePath, bodyLimitHandler(int64(cfg.MaxBodySize), routes))))
+
+	if cfg.Tracing {
+		shutdownTracing, err := startTracing()
+		if err != nil {
+			log.Fatalf("Cannot set up tracing: %v", err)
+		}
+		defer shutdownTracing(context.Background())
+
+		handler = tracingHandler(handler)
+	}
+
+	if cfg.AccessLog != "" {
+		accessLog, err := openAccessLog(cfg.AccessLog)
+		if err != nil {
+			log.Fatalf("Cannot open the access log: %v", err)
+		}
+		if accessLog != os.Stdout {
+			defer accessLog.Close()
+		}
+
+		handler = accessLogHandler(accessLog, cfg.AccessLogFormat, handler)
 	}
 
-	handler := corsHandler.Handler(router)
-	err = http.ListenAndServe(":"+port, handler)
+	listener, err := newListener(cfg.BindAddr, port)
+	if err != nil {
+		log.Fatal("Listen: ", err)
+	}
+
+	// the requests still running when the shutdown timeout passes are
+	// canceled, together with their database lookups
+	requests, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
+
+	server := &http.Server{
+		BaseContext:  func(net.Listener) context.Context { return requests },
+		Handler:      handler,
+		ReadTimeout:  cfg.ReadTimeout.Duration,
+		WriteTimeout: cfg.WriteTimeout.Duration,
+		IdleTimeout:  cfg.IdleTimeout.Duration,
+	}
+
+	go func() {
+		var err error
+		if tlsEnabled(cfg) {
+			err = server.ServeTLS(listener, cfg.TLSCert, cfg.TLSKey)
+		} else {
+			err = server.Serve(listener)
+		}
+
+		if err != nil && err != http.ErrServerClosed {
+			log.Fatal("Serve: ", err)
+		}
+	}()
+
+	// Optionally serve the gRPC interface on a port of its own
+	var rpcServer *grpc.Server
+	if cfg.GRPCPort != "" {
+		rpcServer, err = startGRPC(cfg.BindAddr, cfg.GRPCPort)
+		if err != nil {
+			log.Fatal("Listen gRPC: ", err)
+		}
+	}
+
+	// Optionally redirect plain HTTP requests to HTTPS
+	var redirectServer *http.Server
+	if tlsEnabled(cfg) && cfg.TLSRedirectPort != "" {
+		redirectServer = &http.Server{
+			Addr:         net.JoinHostPort(cfg.BindAddr, cfg.TLSRedirectPort),
+			Handler:      httpsRedirectHandler(port),
+			ReadTimeout:  cfg.ReadTimeout.Duration,
+			WriteTimeout: cfg.WriteTimeout.Duration,
+			IdleTimeout:  cfg.IdleTimeout.Duration,
+		}
+
+		go func() {
+			err := redirectServer.ListenAndServe()
+
+			if err != nil && err != http.ErrServerClosed {
+				log.Fatal("ListenAndServe: ", err)
+			}
+		}()
+	}
+
+	// Reload the config on SIGHUP
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			reloadConfig()
+		}
+	}()
+
+	// Wait for a termination signal, then give in-flight requests
+	// the grace period to complete
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-stop
+
+	log.Printf("Received %v, shutting down", sig)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout.Duration)
+	defer cancel()
+
+	if redirectServer != nil {
+		redirectServer.Shutdown(ctx)
+	}
 
+	err = server.Shutdown(ctx)
 	if err != nil {
-		log.Fatal("ListenAndServe: ", err)
+		log.Printf("Error while shutting down: %v", err)
 	}
+	cancelRequests()
+
+	if rpcServer != nil {
+		rpcServer.GracefulStop()
+	}
+
+	// send the buffered events
+	metrics.Close()
+
+	db.Close()
+}
+
+// Reports whether the static frontend is served. Unless enabled or disabled
+// explicitly, the static template is only hosted when the ENV is 'Live' or 'Test'.
+// The embedded files are served unless a directory is configured, a
+// missing directory disables the file server.
+func serveStatic(config *Config, environment string) bool {
+	enabled := environment == "Live" || environment == "Test"
+	if config.ServeStatic != nil {
+		enabled = *config.ServeStatic
+	}
+
+	if !enabled {
+		return false
+	}
+
+	if config.StaticDir == "" {
+		return true
+	}
+
+	info, err := os.Stat(config.StaticDir)
+	if err != nil || !info.IsDir() {
+		log.Printf("Warning: static directory %v not found, not serving static files", config.StaticDir)
+		return false
+	}
+
+	return true
+}
+
+// Answers requests to unknown routes with a JSON error when no static
+// files are served
+func notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	res, _ := json.MarshalIndent(errorResult(ERROR_NOT_FOUND, "Not found", ""), "", "  ")
+	writeResponse(w, r, http.StatusNotFound, res)
 }
 
 // Processes requests to the /validate/ url
 func validationHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
-	var strRes string
-	config := map[string]bool{}
 	// Set response type to application/json.
 	// See: https://www.owasp.org/index.php/XSS_(Cross_Site_Scripting)_Prevention_Cheat_Sheet#RULE_.233.1_-_HTML_escape_JSON_values_in_an_HTML_context_and_read_the_data_with_JSON.parse
 	w.Header().Add("Content-Type", "application/json; charset=utf-8")
 	// Allow CORS
-	w.Header().Set("Access-Control-Allow-Origin", "*")
+	allowOrigin(w, r)
 
 	// extract iban parameter
 	iban := ps.ByName("iban")
 
-	// check for additional request parameters
-	validateBankCodeQueryParam := r.FormValue("validateBankCode")
-	config["validateBankCode"] = toBoolean(validateBankCodeQueryParam)
+	// a comma-separated list returns an array of results
+	if strings.Contains(iban, ",") {
+		listValidationHandler(w, r, iban)
+		return
+	}
+
+	// reject oversized input before it is parsed or used as a cache key
+	// return HTTP 400
+	if exceedsMaxLength(iban) {
+		writeTooLong(w, r)
+		return
+	}
 
 	// check for additional request parameters
-	getBicQueryParam := r.FormValue("getBIC")
-	config["getBIC"] = toBoolean(getBicQueryParam)
+	config := validationConfig(r)
 
-	// hit the cache
-	value, found := hitCache(iban + strconv.FormatBool(config["getBIC"]) + strconv.FormatBool(config["validateBankCode"]))
-	if found {
-		go logFromCacheEntry(ENV, value)
-		fmt.Fprintf(w, value)
+	expectedCountry, ok := expectedCountryParam(r)
+	if !ok {
+		res, _ := json.MarshalIndent(errorResult(ERROR_INVALID_REQUEST, "Expected a two letter country code as expectedCountry.", iban), "", "  ")
+		writeResponse(w, r, http.StatusBadRequest, res)
+		return
+	}
+
+	accountNumberLength, ok := accountNumberLengthParam(r)
+	if !ok {
+		res, _ := json.MarshalIndent(errorResult(ERROR_INVALID_REQUEST, "Expected a positive number as accountNumberLength.", iban), "", "  ")
+		writeResponse(w, r, http.StatusBadRequest, res)
 		return
 	}
 
+	writeValidation(w, r, iban, config, expectedCountry, accountNumberLength)
+}
+
+// Validates iban and writes the result. Shared by the GET and POST
+// variants of /validate.
+func writeValidation(w http.ResponseWriter, r *http.Request, iban string, config map[string]bool, expectedCountry string, accountNumberLength int) {
 	// no value for request parameter
 	// return HTTP 400
 	if len(iban) == 0 {
-		res, _ := json.MarshalIndent(goiban.NewValidationResult(false, "Empty request.", iban), "", "  ")
-		strRes = string(res)
-		w.Header().Add("Content-Length", strconv.Itoa(len(strRes)))
-		// put to cache and render
-		// c.Set(iban, strRes, 0)
-		http.Error(w, strRes, http.StatusBadRequest)
+		res, _ := json.MarshalIndent(errorResult(ERROR_EMPTY_INPUT, "Empty request.", iban), "", "  ")
+		writeResponse(w, r, http.StatusBadRequest, res)
+		return
+	}
+
+	strRes, err := validate(r.Context(), iban, config)
+
+	// the bank data could not be looked up
+	// return HTTP 503, or HTTP 504 if the lookup timed out
+	if err != nil {
+		result, status := lookupError(err, iban)
+		res, _ := json.MarshalIndent(result, "", "  ")
+		writeResponse(w, r, status, res)
 		return
 	}
 
-	// IBAN is not parseable
-	// return HTTP 200
+	strRes = checkExpectedCountry(strRes, expectedCountry)
+	strRes = checkAccountNumberLength(strRes, accountNumberLength)
+	if config["suggest"] {
+		strRes = addSuggestions(strRes)
+	}
+	if config["debug"] {
+		strRes = addDebugInfo(strRes)
+		w.Header().Set("X-Goiban-Version", goibanVersion())
+	}
+
+	var result struct{ Valid bool }
+	json.Unmarshal([]byte(strRes), &result)
+	writeCacheableResponse(w, r, []byte(strRes), result.Valid)
+}
+
+// Reports whether the input is longer than the configured maximum
+// Whitespace is not counted, it is stripped by normalizeIban
+func exceedsMaxLength(iban string) bool {
+	if cfg.MaxIbanLength <= 0 {
+		return false
+	}
+
+	length := 0
+	for _, r := range iban {
+		if unicode.IsSpace(r) {
+			continue
+		}
+
+		length++
+		if length > cfg.MaxIbanLength {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Writes the HTTP 400 response for oversized input. The input itself
+// is not echoed back.
+func writeTooLong(w http.ResponseWriter, r *http.Request) {
+	msg := fmt.Sprintf("Input exceeds the maximum length of %d characters.", cfg.MaxIbanLength)
+	res, _ := json.MarshalIndent(errorResult(ERROR_INPUT_TOO_LONG, msg, ""), "", "  ")
+	writeResponse(w, r, http.StatusBadRequest, res)
+}
+
+// Reads the additional validation options from the request parameters
+func validationConfig(r *http.Request) map[string]bool {
+	config := map[string]bool{}
+
+	// absent parameters use the configured defaults
+	defaults := settings()
+	config["validateBankCode"] = boolParam(r, "validateBankCode", defaults.DefaultValidateBankCode)
+	config["getBIC"] = boolParam(r, "getBIC", defaults.DefaultGetBIC)
+
+	config["validateNationalChecksum"] = toBoolean(r.FormValue("validateNationalChecksum"))
+	config["includeBankAddress"] = toBoolean(r.FormValue("includeBankAddress"))
+	config["requireBIC"] = toBoolean(r.FormValue("requireBIC"))
+	config["suggest"] = toBoolean(r.FormValue("suggest"))
+	config["includeSepaReachability"] = toBoolean(r.FormValue("includeSepaReachability"))
+	config["includeComponents"] = toBoolean(r.FormValue("includeComponents"))
+	config["debug"] = toBoolean(r.FormValue("debug"))
+
+	if toBoolean(r.FormValue("full")) {
+		enableFull(config)
+	}
+
+	return config
+}
+
+// FULL_OPTIONS are the enrichments turned on by full=true. Options which
+// can fail the validation, like requireBIC, are not part of it.
+var FULL_OPTIONS = []string{"getBIC", "validateBankCode", "includeBankAddress", "includeSepaReachability", "includeComponents"}
+
+// Turns on all FULL_OPTIONS, they override the options given separately.
+// The cache key is built from the options, so a full result is cached
+// like the same options given one by one.
+func enableFull(config map[string]bool) {
+	for _, option := range FULL_OPTIONS {
+		config[option] = true
+	}
+}
+
+// Removes a leading "IBAN" label from the input, see stripIbanPrefix, and
+// compacts the rest, e.g. "iban: de89 3704 0044 0532 0130 00" becomes
+// "DE89370400440532013000".
+func normalizeIban(input string) string {
+	return compactIban(stripIbanPrefix(input))
+}
+
+// Strips all whitespace from the input and converts it to upper case,
+// e.g. "de89 3704 0044 0532 0130 00" becomes "DE89370400440532013000"
+func compactIban(input string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return unicode.ToUpper(r)
+	}, input)
+}
+
+// Removes the "IBAN" label of inputs copied from bank statements, e.g.
+// "IBAN: DE89 3704 0044 0532 0130 00" becomes "DE89 3704 0044 0532 0130 00".
+// The label is matched case-insensitively and only when it is followed by
+// whitespace or a colon and more input, other inputs are returned as is.
+// No country code starts with "IB", so no IBAN is shortened.
+func stripIbanPrefix(input string) string {
+	trimmed := strings.TrimLeftFunc(input, unicode.IsSpace)
+	if len(trimmed) < 4 || !strings.EqualFold(trimmed[:4], "IBAN") {
+		return input
+	}
+
+	rest := strings.TrimLeftFunc(trimmed[4:], func(r rune) bool {
+		return r == ':' || unicode.IsSpace(r)
+	})
+
+	if len(rest) == len(trimmed)-4 || len(rest) == 0 {
+		return input
+	}
+
+	return rest
+}
+
+// Returns a message listing the characters of a normalized IBAN which are
+// neither A-Z nor 0-9, e.g. "Invalid characters found: '-', '.'". Returns
+// "" when all characters are allowed.
+func invalidCharacters(iban string) string {
+	var found []string
+	seen := map[rune]bool{}
+
+	for _, r := range iban {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || seen[r] {
+			continue
+		}
+
+		seen[r] = true
+		found = append(found, "'"+string(r)+"'")
+	}
+
+	if len(found) == 0 {
+		return ""
+	}
+
+	return "Invalid characters found: " + strings.Join(found, ", ")
+}
+
+// Validates a single IBAN and returns the result rendered as JSON.
+// The input is normalized first, so differently formatted inputs share
+// the same cache entry. The result still echoes the original input.
+// An error is returned when the requested bank data could not be looked up.
+// The validation is traced as a child span of ctx.
+func validate(ctx context.Context, input string, config map[string]bool) (string, error) {
+	// the label is stripped once, the result echoes the input without it
+	input = stripIbanPrefix(input)
+	iban := compactIban(input)
+
+	ctx, span := startValidationSpan(ctx, iban)
+	strRes, err := validateNormalized(ctx, iban, config)
+	endSpan(span, err)
+
+	if err == nil && failures != nil {
+		var result struct{ Valid bool }
+		json.Unmarshal([]byte(strRes), &result)
+		failures.record(ctx, result.Valid)
+	}
+
+	if err != nil || iban == input {
+		return strRes, err
+	}
+
+	var result ValidationResponse
+	json.Unmarshal([]byte(strRes), &result)
+	result.Iban = input
+
+	res, _ := json.MarshalIndent(result, "", "  ")
+	return string(res), nil
+}
+
+// Validates a normalized IBAN. Results are served from the cache when
+// possible, otherwise they are put to the cache after validation.
+func validateNormalized(ctx context.Context, iban string, config map[string]bool) (string, error) {
+	key := cacheKey(iban, config)
+
+	// hit the cache, unless the country is no longer allowed after a
+	// reload of the config
+	value, found := "", false
+	if len(iban) < 2 || countryAllowed(goiban.ExtractCountryCode(iban)) {
+		value, found = hitCache(key)
+	}
+
+	if found {
+		go logFromCacheEntry(ENV, value)
+		return value, nil
+	}
+
+	inmemMetrics.RegisterCacheMiss()
+	promMetrics.RegisterCacheMiss()
+
+	// concurrent identical requests share a single validation
+	leader := false
+	strRes, err, _ := validations.Do(key, func() (interface{}, error) {
+		leader = true
+		return validateUncached(ctx, iban, config, key)
+	})
+
+	// a canceled leader doesn't fail the requests waiting for its result
+	if err == context.Canceled && !leader && ctx.Err() == nil {
+		return validateUncached(ctx, iban, config, key)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	// only the leader logged the validation
+	if !leader {
+		go logFromCacheEntry(ENV, strRes.(string))
+	}
+
+	return strRes.(string), nil
+}
+
+// Validates a normalized IBAN, logs the metrics and puts the result to
+// the cache under key.
+func validateUncached(ctx context.Context, iban string, config map[string]bool, key string) (string, error) {
+	start := time.Now()
+	defer registerLatency("validation", start)
+
+	// IBAN is longer than any IBAN can be
+	if len(iban) > MAX_IBAN_LENGTH {
+		result := errorResult(ERROR_IBAN_TOO_LONG, fmt.Sprintf("IBAN exceeds maximum length of %d characters.", MAX_IBAN_LENGTH), iban)
+		res, _ := json.MarshalIndent(result, "", "  ")
+		strRes := string(res)
+
+		registerValidation("", result.ValidationResult)
+
+		// put to cache
+		setCache(key, strRes, false)
+		return strRes, nil
+	}
+
+	// IBAN is not parseable, illegal characters are reported explicitly
 	parserResult := goiban.IsParseable(iban)
+	message := invalidCharacters(iban)
+
+	if message != "" || !parserResult.Valid {
+		if message == "" {
+			message = "Cannot parse as IBAN: " + parserResult.Message
+		}
 
-	if !parserResult.Valid {
-		res, _ := json.MarshalIndent(goiban.NewValidationResult(false, "Cannot parse as IBAN: "+parserResult.Message, iban), "", "  ")
-		strRes = string(res)
-		w.Header().Add("Content-Length", strconv.Itoa(len(strRes)))
+		result := errorResult(ERROR_NOT_PARSEABLE, message, iban)
+		res, _ := json.MarshalIndent(result, "", "  ")
+		strRes := string(res)
 
-		// put to cache and render
-		c.Set(iban+strconv.FormatBool(config["getBIC"])+strconv.FormatBool(config["validateBankCode"]), strRes, 0)
-		fmt.Fprintf(w, strRes)
-		return
+		registerValidation("", result.ValidationResult)
+
+		// put to cache
+		setCache(key, strRes, false)
+		return strRes, nil
+	}
+
+	// IBAN is from a country which is not allowed
+	countryCode := goiban.ExtractCountryCode(iban)
+	if !countryAllowed(countryCode) {
+		result := errorResult(ERROR_COUNTRY_UNSUPPORTED, "Country not supported: "+countryCode, iban)
+		res, _ := json.MarshalIndent(result, "", "  ")
+
+		registerValidation("", result.ValidationResult)
+		return string(res), nil
 	}
 
-	// Try to validate
 	parsedIban := goiban.ParseToIban(iban)
-	result := parsedIban.Validate()
 
-	// intermediate result
-	if len(config) > 0 {
-		result = additionalData(parsedIban, result, config)
+	// Check the length and the BBAN format first to report the most
+	// specific error, then try to validate
+	var errorCode, nationalChecksumStatus, source string
+	var sepa *SepaReachability
+	var isQRIban *bool
+	var components *BbanComponents
+	result := validateLength(parsedIban.GetCountryCode(), iban)
+
+	if result != nil {
+		errorCode = ERROR_BAD_LENGTH
+	} else if result = validateBbanFormat(parsedIban.GetCountryCode(), iban); result != nil {
+		errorCode = ERROR_STRUCTURE_MISMATCH
+	} else {
+		result = parsedIban.Validate()
+		isQRIban = qrIban(iban)
+
+		if config["includeComponents"] {
+			components = bbanComponents(iban)
+		}
+
+		// intermediate result
+		if len(config) > 0 {
+			var err error
+			result, err = additionalData(ctx, parsedIban, result, config)
+			if err != nil {
+				log.Printf("Error while looking up bank data for %v: %v", m.SafeIban(iban), err)
+				return "", err
+			}
+		}
+
+		errorCode = validationErrorCode(result)
+
+		// strict integrations can't use IBANs without a BIC
+		if config["getBIC"] && config["requireBIC"] && result.Valid && result.BankData.Bic == "" {
+			result.Valid = false
+			result.Messages = append(result.Messages, "No BIC found for the bank code.")
+			errorCode = ERROR_BIC_NOT_FOUND
+		}
+
+		if config["getBIC"] && config["includeSepaReachability"] {
+			_, span := startLookupSpan(ctx, "sepa_reachability")
+			var err error
+			sepa, err = sepaReachability(ctx, parsedIban.GetCountryCode(), result)
+			endSpan(span, err)
+			if err != nil {
+				log.Printf("Error while looking up the SEPA reachability of %v: %v", m.SafeIban(iban), err)
+				return "", err
+			}
+		}
+
+		if config["getBIC"] {
+			_, span := startLookupSpan(ctx, "bic_source")
+			var err error
+			source, err = bicSource(ctx, parsedIban.GetCountryCode(), result)
+			endSpan(span, err)
+			if err != nil {
+				log.Printf("Error while looking up the BIC source of %v: %v", m.SafeIban(iban), err)
+				return "", err
+			}
+		}
+
+		// the check method of German accounts is part of the bank data
+		if config["validateNationalChecksum"] && parsedIban.GetCountryCode() == "DE" {
+			_, span := startLookupSpan(ctx, "check_method")
+			var err error
+			nationalChecksumStatus, err = germanNationalChecksum(ctx, iban)
+			endSpan(span, err)
+			if err != nil {
+				log.Printf("Error while looking up the check method of %v: %v", m.SafeIban(iban), err)
+				return "", err
+			}
+		} else if config["validateNationalChecksum"] {
+			nationalChecksumStatus = nationalChecksum(iban)
+		}
+	}
+
+	response := ValidationResponse{
+		ValidationResult: result,
+		CountryName:      countryName(parsedIban.GetCountryCode()),
+		ErrorCode:        errorCode,
+		NationalChecksum: nationalChecksumStatus,
+		SepaReachability: sepa,
+		BicSource:        source,
+		QRIban:           isQRIban,
+		Components:       components,
 	}
 
-	res, err := json.MarshalIndent(result, "", "  ")
+	res, err := json.MarshalIndent(response, "", "  ")
 	if err != nil {
 		fmt.Println(err)
 	}
 
-	strRes = string(res)
-	w.Header().Add("Content-Length", strconv.Itoa(len(strRes)))
-	// put to cache and render
+	strRes := string(res)
 
 	go logFromIbanResult(ENV, parsedIban)
+	registerValidation(parsedIban.GetCountryCode(), result)
 
-	key := iban + strconv.FormatBool(config["getBIC"]) + strconv.FormatBool(config["validateBankCode"])
+	// put to cache
+	setCache(key, strRes, result.Valid)
+	return strRes, nil
+}
 
-	c.Set(key, strRes, 0)
-	fmt.Fprintf(w, strRes)
-	return
+// Reports whether IBANs of the country are validated. All countries are
+// allowed when no allowed countries are configured.
+func countryAllowed(countryCode string) bool {
+	countries := settings().AllowedCountries
+	if len(countries) == 0 {
+		return true
+	}
+
+	for _, allowed := range countries {
+		if strings.EqualFold(allowed, countryCode) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns the key of the cached result of a normalized IBAN validated with
+// config. With the CacheKeyHash setting the key is the hex SHA-256 of the
+// IBAN and options, so every key has 64 characters. The IBAN is upper case
+// and the options are spelled in lower case, so distinct IBANs and options
+// never produce the same key.
+func cacheKey(iban string, config map[string]bool) string {
+	key := iban + strconv.FormatBool(config["getBIC"]) + strconv.FormatBool(config["validateBankCode"]) +
+		strconv.FormatBool(config["validateNationalChecksum"]) + strconv.FormatBool(config["includeBankAddress"]) +
+		strconv.FormatBool(config["requireBIC"]) + strconv.FormatBool(config["includeSepaReachability"]) +
+		strconv.FormatBool(config["includeComponents"])
+
+	if !cfg.CacheKeyHash {
+		return key
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Sets the Access-Control-Allow-Origin header when the origin of the
+// request is one of the allowed origins.
+func allowOrigin(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get("Origin")
+
+	for _, allowed := range settings().AllowedOrigins {
+		if allowed == "*" {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			return
+		}
+
+		if origin != "" && allowed == origin {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Add("Vary", "Origin")
+			return
+		}
+	}
+}
+
+// Reports whether requests from origin are allowed, used for the CORS
+// preflight requests
+func originAllowed(origin string) bool {
+	for _, allowed := range settings().AllowedOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns the boolean request parameter name, or fallback when the
+// request doesn't contain it
+func boolParam(r *http.Request, name string, fallback bool) bool {
+	value := r.FormValue(name)
+	if _, ok := r.Form[name]; !ok {
+		return fallback
+	}
+
+	return toBoolean(value)
 }
 
 func toBoolean(value string) bool {
@@ -189,46 +960,204 @@ func toBoolean(value string) bool {
 	}
 }
 
-func additionalData(iban *goiban.Iban, intermediateResult *goiban.ValidationResult, config map[string]bool) *goiban.ValidationResult {
+// Looks up the requested bank data. While the circuit breaker is open
+// the lookups fail right away.
+func additionalData(ctx context.Context, iban *goiban.Iban, intermediateResult *goiban.ValidationResult, config map[string]bool) (*goiban.ValidationResult, error) {
+	if !config["validateBankCode"] && !config["getBIC"] {
+		return intermediateResult, nil
+	}
+
+	err := dbBreaker.allow()
+	if err != nil {
+		return intermediateResult, err
+	}
+
+	result, err := lookupBankData(ctx, iban, intermediateResult, config)
+	dbBreaker.record(err)
+
+	return result, err
+}
+
+// Looks up the bank data of additionalData. goiban doesn't report database
+// errors, so the connection is checked before any lookup is made.
+func lookupBankData(ctx context.Context, iban *goiban.Iban, intermediateResult *goiban.ValidationResult, config map[string]bool) (*goiban.ValidationResult, error) {
+	// structural validations aren't limited, only the lookups wait
+	slot, err := acquireDBSlot()
+	if err != nil {
+		return intermediateResult, err
+	}
+	defer slot.release()
+
+	// goiban doesn't report errors of the lookups, a broken connection is
+	// detected (and retried) before
+	err = withDBRetry(db.Ping)
+	if err != nil {
+		return intermediateResult, err
+	}
+
+	err = checkSchema(ctx)
+	if err != nil {
+		return intermediateResult, err
+	}
+
 	validateBankCode, ok := config["validateBankCode"]
 	if ok && validateBankCode {
-		intermediateResult = goiban.ValidateBankCode(iban, intermediateResult, db)
+		promMetrics.RegisterLookup("bank_code")
+		_, span := startLookupSpan(ctx, "bank_code")
+		start := time.Now()
+		intermediateResult, err = withLookupTimeout(ctx, slot, intermediateResult, func(result *goiban.ValidationResult) *goiban.ValidationResult {
+			return goiban.ValidateBankCode(iban, result, db)
+		})
+		registerLatency("bank_code", start)
+		endSpan(span, err)
+		if err != nil {
+			return intermediateResult, err
+		}
 	}
 
 	getBic, ok := config["getBIC"]
 	if ok && getBic {
-		intermediateResult = goiban.GetBic(iban, intermediateResult, db)
+		promMetrics.RegisterLookup("bic")
+		_, span := startLookupSpan(ctx, "bic")
+		start := time.Now()
+		intermediateResult, err = withLookupTimeout(ctx, slot, intermediateResult, func(result *goiban.ValidationResult) *goiban.ValidationResult {
+			return goiban.GetBic(iban, result, db)
+		})
+		registerLatency("bic", start)
+		endSpan(span, err)
+		if err != nil {
+			return intermediateResult, err
+		}
+
+		if config["includeBankAddress"] {
+			_, span := startLookupSpan(ctx, "bank_address")
+			err = addBankAddress(ctx, iban.GetCountryCode(), intermediateResult)
+			endSpan(span, err)
+			if err != nil {
+				return intermediateResult, err
+			}
+		}
+	}
+	return intermediateResult, nil
+}
+
+// Puts value to the cache using the configured expiration. Invalid results
+// use the (usually shorter) negative cache TTL. Nothing is cached when the
+// cache TTL is 0.
+func setCache(key string, value string, valid bool) {
+	ttl := cfg.CacheTTL.Duration
+	if ttl == 0 {
+		return
+	}
+
+	if !valid {
+		ttl = cfg.NegativeCacheTTL.Duration
+		if ttl == 0 {
+			return
+		}
 	}
-	return intermediateResult
+
+	c.Set(key, value, ttl)
 }
 
 func hitCache(iban string) (string, bool) {
-	val, ok := c.Get(iban)
-	if ok {
-		return val.(string), ok
+	value, found := c.Get(iban)
+	if found {
+		inmemMetrics.RegisterCacheHit()
+		promMetrics.RegisterCacheHit()
+	}
+
+	return value, found
+}
+
+// Counts a validation in the metrics registers
+func registerValidation(countryCode string, result *goiban.ValidationResult) {
+	if !cfg.Metrics {
+		return
+	}
+
+	inmemMetrics.RegisterResult(result.Valid)
+	inmemMetrics.RegisterOutcome(outcomeCountry(result.Iban), validationOutcome(result))
+	promMetrics.RegisterValidation(countryCode, result)
+}
+
+// Returns the country of a validated input for the outcome metrics: the
+// first two characters, even of unparseable input, or m.UNKNOWN_COUNTRY
+// when they are no country code.
+func outcomeCountry(iban string) string {
+	if len(iban) < 2 || !isLetter(iban[0]) || !isLetter(iban[1]) {
+		return m.UNKNOWN_COUNTRY
 	}
 
-	return "", false
+	return iban[:2]
+}
 
+func isLetter(c byte) bool {
+	return c >= 'A' && c <= 'Z'
 }
 
-// Only logs when metrics is defined
+// Returns the outcome of a validation for the outcome metrics
+func validationOutcome(result *goiban.ValidationResult) string {
+	switch {
+	case result.Valid:
+		return m.OUTCOME_VALID
+	case invalidCharacters(result.Iban) != "" || !goiban.IsParseable(result.Iban).Valid:
+		return m.OUTCOME_UNPARSEABLE
+	}
+
+	return m.OUTCOME_INVALID
+}
+
+// Counts a result evicted from the full in-memory cache
+func registerCacheEviction() {
+	inmemMetrics.RegisterCacheEviction()
+	promMetrics.RegisterCacheEviction()
+}
+
+// Records the number of results held by the in-memory cache
+func registerCacheSize(size int) {
+	inmemMetrics.RegisterCacheSize(size)
+	promMetrics.RegisterCacheSize(size)
+}
+
+// Records the state of the database circuit breaker
+func registerBreakerState(state int) {
+	inmemMetrics.RegisterBreakerState(state)
+	promMetrics.RegisterBreakerState(state)
+}
+
+// Records the time passed since start as the latency of the operation name
+func registerLatency(name string, start time.Time) {
+	d := time.Since(start)
+
+	inmemMetrics.RegisterLatency(name, d)
+	promMetrics.RegisterLatency(name, d)
+}
+
+// Logs to the metrics backend when metrics are enabled
 func logFromCacheEntry(ENV string, value string) {
-	if metrics != nil {
-		metrics.LogRequestFromValidationResult(ENV, value)
-	} else {
-		var result *goiban.ValidationResult
-		json.Unmarshal([]byte(value), &result)
+	if !cfg.Metrics {
+		return
+	}
+
+	var result *goiban.ValidationResult
+	json.Unmarshal([]byte(value), &result)
 
-		inmemMetrics.Register(m.ValidationResultToEvent(result))
+	metrics.LogRequestFromValidationResult(ENV, value)
+
+	// Only parseable IBANs are counted per country
+	countryCode := ""
+	if goiban.IsParseable(result.Iban).Valid {
+		countryCode = goiban.ExtractCountryCode(result.Iban)
 	}
+	registerValidation(countryCode, result)
 }
 
-// Only logs when metrics is defined
+// Logs to the metrics backend when metrics are enabled
 func logFromIbanResult(ENV string, value *goiban.Iban) {
-	if metrics != nil {
-		metrics.WriteLogRequest(ENV, value)
-	} else {
-		inmemMetrics.Register(m.IbanToEvent(value))
+	if !cfg.Metrics {
+		return
 	}
+
+	metrics.WriteLogRequest(ENV, value)
 }
@@ -26,16 +26,22 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"database/sql"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"testing"
 
 	"encoding/json"
+	"encoding/xml"
 
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/fourcube/goiban"
 	"github.com/julienschmidt/httprouter"
@@ -46,9 +52,29 @@ var server *httptest.Server
 func TestMain(m *testing.M) {
 	router := httprouter.New()
 	router.GET("/v2/calculate/:countryCode/:bankCode/:accountNumber", calculateAndValidateIBAN)
+	router.GET("/calculate", calculateIBANFromQuery)
+	router.GET("/calculate/:countryCode", calculationCountriesHandler)
 	router.GET("/calculate/:countryCode/:bankCode/:accountNumber", calculateIBAN)
 	router.GET("/validate/:iban", validationHandler)
+	router.GET("/v2/validate/:iban", validationHandlerV2)
+	router.GET("/validate-bban/:countryCode/:bban", bbanValidationHandler)
+	router.GET("/checkdigits/:countryCode/:bban", checkDigitsHandler)
+	router.POST("/validate", postValidationHandler)
+	router.POST("/validate/batch", batchValidationHandler)
+	router.POST("/validate/csv", csvValidationHandler)
 	router.GET("/countries", countryCodeHandler)
+	router.GET("/bic/:bic", bicLookupHandler)
+	router.GET("/banks/:countryCode", banksHandler)
+	router.GET("/format/:iban", formatHandler)
+	router.GET("/epc-qr", epcQRHandler)
+	router.GET("/stats", statsHandler)
+	router.POST("/metrics/reset", metricsResetHandler)
+	router.GET("/health", healthHandler)
+	router.GET("/ready", readyHandler)
+	router.GET("/version", versionHandler)
+	router.POST("/admin/banks/import", bankImportHandler)
+	router.Handler("GET", "/metrics/prometheus", http.Handler(promMetrics))
+	router.NotFound = http.HandlerFunc(notFoundHandler)
 	server = httptest.NewServer(router)
 
 	db, err = sql.Open("mysql", "root:root@/goiban?charset=utf8")
@@ -102,12 +128,642 @@ func TestIbanTooShort(t *testing.T) {
 		t.Errorf("Expected success %v", err)
 	}
 
-	if !strings.Contains(result.Messages[0], "Expected length") {
+	if !strings.Contains(result.Messages[0], "Expected 27 characters for IT") {
 		t.Errorf("Expected length error %v", result)
 	}
 
 }
 
+func TestIbanLengthMismatch(t *testing.T) {
+	resp, _ := http.Get(server.URL + "/validate/DE893704004405320130")
+	decoder := json.NewDecoder(resp.Body)
+	var result goiban.ValidationResult
+
+	err = decoder.Decode(&result)
+	if err != nil {
+		t.Errorf("Expected success %v", err)
+	}
+
+	if result.Valid || result.Messages[0] != "Expected 22 characters for DE, got 20" {
+		t.Errorf("Expected length error %v", result)
+	}
+}
+
+func TestCountryNameIsReturned(t *testing.T) {
+	resp, _ := http.Get(server.URL + "/validate/DE89370400440532013000")
+	var result ValidationResponse
+
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	resp.Body.Close()
+	if err != nil {
+		t.Errorf("Expected success %v", err)
+	}
+
+	if result.CountryName == "" || goiban.COUNTRY_TO_CC_MAP[result.CountryName] != "DE" {
+		t.Errorf("Expected the name of DE, got %q", result.CountryName)
+	}
+}
+
+func TestCountryNameOmittedForUnparseableIban(t *testing.T) {
+	resp, _ := http.Get(server.URL + "/validate/D")
+	var result map[string]interface{}
+
+	json.NewDecoder(resp.Body).Decode(&result)
+	resp.Body.Close()
+
+	if _, ok := result["countryName"]; ok {
+		t.Errorf("Expected no country name, got %v", result["countryName"])
+	}
+}
+
+func TestXMLFormat(t *testing.T) {
+	cases := map[string]string{
+		"/validate/DE89370400440532013000?format=xml": "",
+		"/validate/DE89370400440532013000":            "application/xml",
+	}
+
+	for path, accept := range cases {
+		req, _ := http.NewRequest("GET", server.URL+path, nil)
+		req.Header.Set("Accept", accept)
+
+		resp, _ := http.DefaultClient.Do(req)
+		var result struct {
+			Valid bool   `xml:"valid"`
+			Iban  string `xml:"iban"`
+		}
+
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			t.Errorf("Expected success %v", err)
+		}
+
+		if contentType := resp.Header.Get("Content-Type"); contentType != "application/xml; charset=utf-8" {
+			t.Errorf("Content type was %v instead of application/xml", contentType)
+		}
+
+		if !result.Valid || result.Iban != "DE89370400440532013000" {
+			t.Errorf("Unexpected XML result %v", result)
+		}
+	}
+}
+
+func TestTextFormat(t *testing.T) {
+	cases := map[string]string{
+		"/validate/DE89370400440532013000?format=text":                        "true",
+		"/validate/DE89370400440532013001?format=text":                        "false",
+		"/validate/DE89370400440532013000,DE89370400440532013001?format=text": "true\nfalse",
+	}
+
+	for path, expected := range cases {
+		resp, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Errorf("failed to validate %v %v", path, err)
+			t.FailNow()
+		}
+
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if contentType := resp.Header.Get("Content-Type"); contentType != "text/plain; charset=utf-8" {
+			t.Errorf("Content type was %v instead of text/plain", contentType)
+		}
+
+		if resp.StatusCode != http.StatusOK || string(body) != expected {
+			t.Errorf("expected %q for %v, got %v %q", expected, path, resp.StatusCode, body)
+		}
+	}
+
+	resp, _ := http.Get(server.URL + "/validate/DE89370400440532013000?format=text&expectedCountry=DEU")
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest || string(body) != "false" {
+		t.Errorf("expected the status of the JSON response, got %v %q", resp.StatusCode, body)
+	}
+}
+
+func TestZeroCacheTTLDisablesCache(t *testing.T) {
+	defer func(ttl time.Duration) { cfg.CacheTTL.Duration = ttl }(cfg.CacheTTL.Duration)
+	cfg.CacheTTL.Duration = 0
+
+	key := cacheKey("DE89370400440532013000", map[string]bool{})
+	c.Delete(key)
+	validate(context.Background(), "DE89370400440532013000", map[string]bool{})
+
+	if _, found := hitCache(key); found {
+		t.Errorf("expected result not to be cached")
+	}
+}
+
+func TestUnavailableDatabaseReturns503(t *testing.T) {
+	defer func(original *sql.DB) { db = original }(db)
+	db, _ = sql.Open("mysql", "root:root@tcp(127.0.0.1:1)/goiban")
+
+	resp, _ := http.Get(server.URL + "/validate/DE89370400440532013000?getBIC=true")
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected status %v, got %v", http.StatusServiceUnavailable, resp.StatusCode)
+	}
+}
+
+func TestAllowedOriginIsReflected(t *testing.T) {
+	defer func(origins []string) { cfg.AllowedOrigins = origins }(cfg.AllowedOrigins)
+	cfg.AllowedOrigins = []string{"https://openiban.com"}
+
+	for origin, expected := range map[string]string{
+		"https://openiban.com": "https://openiban.com",
+		"https://example.com":  "",
+	} {
+		req, _ := http.NewRequest("GET", server.URL+"/validate/DE89370400440532013000", nil)
+		req.Header.Set("Origin", origin)
+		resp, _ := http.DefaultClient.Do(req)
+		resp.Body.Close()
+
+		if allowed := resp.Header.Get("Access-Control-Allow-Origin"); allowed != expected {
+			t.Errorf("expected allowed origin %q for %v, got %q", expected, origin, allowed)
+		}
+	}
+}
+
+func TestZeroNegativeCacheTTLSkipsInvalidResults(t *testing.T) {
+	defer func(ttl time.Duration) { cfg.NegativeCacheTTL.Duration = ttl }(cfg.NegativeCacheTTL.Duration)
+	cfg.NegativeCacheTTL.Duration = 0
+
+	for iban, cached := range map[string]bool{
+		"DE89370400440532013000": true,
+		"DE89370400440532013001": false,
+	} {
+		key := cacheKey(iban, map[string]bool{})
+		c.Delete(key)
+		validate(context.Background(), iban, map[string]bool{})
+
+		if _, found := hitCache(key); found != cached {
+			t.Errorf("expected result of %v to be cached: %v", iban, cached)
+		}
+	}
+}
+
+func TestOversizedInputIsRejected(t *testing.T) {
+	input := "DE89370400440532013000" + strings.Repeat("0", cfg.MaxIbanLength)
+
+	for _, path := range []string{"/validate/", "/v2/validate/"} {
+		resp, err := http.Get(server.URL + path + input)
+		if err != nil {
+			t.Errorf("failed to request %v %v", path, err)
+			t.FailNow()
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected HTTP 400 for %v, got %v", path, resp.StatusCode)
+		}
+	}
+
+	if _, found := hitCache(cacheKey(input, map[string]bool{})); found {
+		t.Errorf("oversized input must not be cached")
+	}
+}
+
+func TestFormattedInputSharesCacheEntry(t *testing.T) {
+	input := "de89 3704 0044 0532 0130 00"
+	key := cacheKey("DE89370400440532013000", map[string]bool{})
+	c.Delete(key)
+
+	res, err := http.Get(server.URL + "/validate/" + url.PathEscape(input))
+	if err != nil {
+		t.Errorf("failed to validate formatted IBAN %v", err)
+		t.FailNow()
+	}
+
+	var result ValidationResponse
+	err = json.NewDecoder(res.Body).Decode(&result)
+	res.Body.Close()
+	if err != nil {
+		t.Errorf("failed to decode result %v", err)
+		t.FailNow()
+	}
+
+	if !result.Valid {
+		t.Errorf("expected %v to be valid: %v", input, result.Messages)
+	}
+
+	if result.Iban != input {
+		t.Errorf("expected the original input to be echoed, got %v", result.Iban)
+	}
+
+	if _, found := hitCache(key); !found {
+		t.Errorf("expected the result to be cached under the normalized IBAN")
+	}
+}
+
+func TestIbanPrefixIsStripped(t *testing.T) {
+	inputs := map[string]string{
+		"IBAN: DE89 3704 0044 0532 0130 00": "DE89 3704 0044 0532 0130 00",
+		"iban DE89370400440532013000":       "DE89370400440532013000",
+		"IBAN:DE89370400440532013000":       "DE89370400440532013000",
+	}
+
+	for input, cleaned := range inputs {
+		res, err := http.Get(server.URL + "/validate/" + url.PathEscape(input))
+		if err != nil {
+			t.Errorf("failed to validate %v %v", input, err)
+			t.FailNow()
+		}
+
+		var result ValidationResponse
+		err = json.NewDecoder(res.Body).Decode(&result)
+		res.Body.Close()
+		if err != nil {
+			t.Errorf("failed to decode result %v", err)
+			t.FailNow()
+		}
+
+		if !result.Valid {
+			t.Errorf("expected %v to be valid: %v", input, result.Messages)
+		}
+
+		if result.Iban != cleaned {
+			t.Errorf("expected %v to be echoed for %v, got %v", cleaned, input, result.Iban)
+		}
+	}
+
+	// the label is stripped only once
+	strRes, err := validate(context.Background(), "IBAN IBAN DE89370400440532013000", map[string]bool{})
+	if err != nil {
+		t.Errorf("failed to validate %v", err)
+		t.FailNow()
+	}
+
+	var result ValidationResponse
+	json.Unmarshal([]byte(strRes), &result)
+
+	if result.Valid || result.Iban != "IBAN DE89370400440532013000" {
+		t.Errorf("expected a repeated label to be kept, got %v", strRes)
+	}
+
+	// only a separated label is stripped
+	for _, input := range []string{"IBANDE89370400440532013000", "IBAN", "IBAN: "} {
+		if stripIbanPrefix(input) != input {
+			t.Errorf("expected %q to be kept, got %q", input, stripIbanPrefix(input))
+		}
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	cases := map[string]string{
+		"DE89370400440532013000": "",
+		"DE89370400440532013001": ERROR_BAD_CHECKSUM,
+		"DE8937040044053201300":  ERROR_BAD_LENGTH,
+		"D":                      ERROR_NOT_PARSEABLE,
+	}
+
+	for iban, code := range cases {
+		res, err := http.Get(server.URL + "/validate/" + iban)
+		if err != nil {
+			t.Errorf("failed to validate %v", err)
+			t.FailNow()
+		}
+
+		var result ValidationResponse
+		json.NewDecoder(res.Body).Decode(&result)
+		res.Body.Close()
+
+		if result.ErrorCode != code {
+			t.Errorf("expected error code %q for %v, got %q", code, iban, result.ErrorCode)
+		}
+	}
+}
+
+func TestFullEnablesAllEnrichments(t *testing.T) {
+	req := httptest.NewRequest("GET", "/validate/DE89370400440532013000?full=true&getBIC=false", nil)
+	config := validationConfig(req)
+
+	for _, option := range FULL_OPTIONS {
+		if !config[option] {
+			t.Errorf("expected full=true to turn on %v", option)
+		}
+	}
+
+	if config["requireBIC"] || config["validateNationalChecksum"] {
+		t.Errorf("expected full=true to leave the checks which can fail the validation off, got %v", config)
+	}
+
+	explicit := validationConfig(httptest.NewRequest("GET", "/validate/DE89370400440532013000?getBIC=true&validateBankCode=true&includeBankAddress=true&includeSepaReachability=true&includeComponents=true", nil))
+	plain := validationConfig(httptest.NewRequest("GET", "/validate/DE89370400440532013000?getBIC=false&validateBankCode=false", nil))
+
+	key := cacheKey("DE89370400440532013000", config)
+	if key != cacheKey("DE89370400440532013000", explicit) {
+		t.Errorf("expected full=true to share the cache key of the same options given one by one")
+	}
+	if key == cacheKey("DE89370400440532013000", plain) {
+		t.Errorf("expected full=true not to share the cache key of the plain result")
+	}
+}
+
+func TestIbanTooLong(t *testing.T) {
+	cases := map[string]bool{
+		// 35 characters
+		"DE893704004405320130001234567890123": true,
+		// 35 characters once the spaces are removed
+		"DE89 3704 0044 0532 0130 0012 3456 7890 123": true,
+		// 34 characters are allowed, this one fails the country length check
+		"DE89370400440532013000123456789012": false,
+		"MT84MALT011000012345MTLCAST001S":    false,
+	}
+
+	for iban, tooLong := range cases {
+		strRes, err := validate(context.Background(), iban, map[string]bool{})
+		if err != nil {
+			t.Errorf("failed to validate %v", err)
+			t.FailNow()
+		}
+
+		var result ValidationResponse
+		json.Unmarshal([]byte(strRes), &result)
+
+		if (result.ErrorCode == ERROR_IBAN_TOO_LONG) != tooLong {
+			t.Errorf("expected IBAN_TOO_LONG %v for %v, got %q", tooLong, iban, result.ErrorCode)
+		}
+
+		if tooLong && (result.Valid || len(result.Messages) != 1 || result.Messages[0] != "IBAN exceeds maximum length of 34 characters.") {
+			t.Errorf("expected the maximum length to be reported for %v, got %v", iban, result.Messages)
+		}
+	}
+
+	strRes, _ := validate(context.Background(), "MT84MALT011000012345MTLCAST001S", map[string]bool{})
+	if !strings.Contains(strRes, "\"valid\": true") {
+		t.Errorf("expected the Maltese IBAN to be valid, got %v", strRes)
+	}
+}
+
+func TestInvalidCharactersAreReported(t *testing.T) {
+	strRes, err := validate(context.Background(), "DE89-3704.0044-0532.0130-00", map[string]bool{})
+	if err != nil {
+		t.Errorf("failed to validate %v", err)
+		t.FailNow()
+	}
+
+	var result ValidationResponse
+	json.Unmarshal([]byte(strRes), &result)
+
+	expected := "Invalid characters found: '-', '.'"
+	if result.Valid || result.ErrorCode != ERROR_NOT_PARSEABLE || len(result.Messages) != 1 || result.Messages[0] != expected {
+		t.Errorf("expected the illegal characters to be listed, got %v", strRes)
+	}
+
+	if message := invalidCharacters("DE89370400440532013000"); message != "" {
+		t.Errorf("expected no invalid characters, got %v", message)
+	}
+}
+
+func TestDisallowedCountryIsRejected(t *testing.T) {
+	defer func(countries []string) { cfg.AllowedCountries = countries }(cfg.AllowedCountries)
+	cfg.AllowedCountries = []string{"AT", "CH"}
+	c.Delete(cacheKey("DE89370400440532013000", map[string]bool{}))
+
+	strRes, err := validate(context.Background(), "DE89370400440532013000", map[string]bool{})
+	if err != nil {
+		t.Errorf("failed to validate %v", err)
+		t.FailNow()
+	}
+
+	var result ValidationResponse
+	json.Unmarshal([]byte(strRes), &result)
+
+	if result.Valid || result.ErrorCode != ERROR_COUNTRY_UNSUPPORTED {
+		t.Errorf("expected the country to be rejected, got %v", strRes)
+	}
+
+	strRes, _ = validate(context.Background(), "D", map[string]bool{})
+	json.Unmarshal([]byte(strRes), &result)
+
+	if result.ErrorCode != ERROR_NOT_PARSEABLE {
+		t.Errorf("expected parse errors to be reported first, got %v", strRes)
+	}
+}
+
+func TestCachedResultOfDisallowedCountryIsRejected(t *testing.T) {
+	defer func() { reloaded = nil }()
+	c.Delete(cacheKey("DE89370400440532013000", map[string]bool{}))
+
+	// cache the result while every country is allowed
+	validate(context.Background(), "DE89370400440532013000", map[string]bool{})
+
+	reloaded = reloadableSettings(cfg)
+	reloaded.AllowedCountries = []string{"AT"}
+
+	strRes, err := validate(context.Background(), "DE89370400440532013000", map[string]bool{})
+	if err != nil {
+		t.Errorf("failed to validate %v", err)
+		t.FailNow()
+	}
+
+	var result ValidationResponse
+	json.Unmarshal([]byte(strRes), &result)
+
+	if result.Valid || result.ErrorCode != ERROR_COUNTRY_UNSUPPORTED {
+		t.Errorf("expected the cached result to be rejected after the reload, got %v", strRes)
+	}
+}
+
+func TestServeStatic(t *testing.T) {
+	config := defaultConfig()
+	if !serveStatic(config, "Test") || serveStatic(config, "Dev") {
+		t.Errorf("expected static files to be served depending on the env by default")
+	}
+
+	enabled := true
+	config.ServeStatic = &enabled
+	if !serveStatic(config, "Dev") {
+		t.Errorf("expected static files to be served when enabled")
+	}
+
+	config.StaticDir = "does-not-exist"
+	if serveStatic(config, "Dev") {
+		t.Errorf("expected a missing static directory to be skipped")
+	}
+}
+
+func TestNationalChecksumFlag(t *testing.T) {
+	for path, expected := range map[string]string{
+		"/validate/BE68539007547034?validateNationalChecksum=true": STATUS_VALID,
+		"/validate/BE68539007547034":                               "",
+	} {
+		res, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Errorf("failed to validate %v", err)
+			t.FailNow()
+		}
+
+		var result ValidationResponse
+		json.NewDecoder(res.Body).Decode(&result)
+		res.Body.Close()
+
+		if result.NationalChecksum != expected {
+			t.Errorf("expected national checksum %q for %v, got %q", expected, path, result.NationalChecksum)
+		}
+	}
+}
+
+func TestUnknownRouteReturnsJSON(t *testing.T) {
+	res, err := http.Get(server.URL + "/does/not/exist")
+	if err != nil {
+		t.Errorf("failed to request unknown route %v", err)
+		t.FailNow()
+	}
+
+	var result ValidationResponse
+	err = json.NewDecoder(res.Body).Decode(&result)
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusNotFound || err != nil {
+		t.Errorf("expected a JSON 404, got %v (%v)", res.StatusCode, err)
+	}
+
+	if contentType := res.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "application/json") {
+		t.Errorf("unexpected content type %v", contentType)
+	}
+
+	if result.Valid || len(result.Messages) != 1 || result.Messages[0] != "Not found" {
+		t.Errorf("unexpected result %v", result)
+	}
+}
+
+func TestExpectedCountry(t *testing.T) {
+	cases := map[string]bool{
+		"DE": true,
+		"at": false,
+	}
+
+	for expected, valid := range cases {
+		res, err := http.Get(server.URL + "/validate/DE89370400440532013000?expectedCountry=" + expected)
+		if err != nil {
+			t.Errorf("failed to validate %v", err)
+			t.FailNow()
+		}
+
+		var result ValidationResponse
+		json.NewDecoder(res.Body).Decode(&result)
+		res.Body.Close()
+
+		if result.Valid != valid {
+			t.Errorf("expected valid to be %v for expected country %v", valid, expected)
+		}
+
+		if result.StructureValid == nil || !*result.StructureValid {
+			t.Errorf("expected the structure to be reported as valid for %v", expected)
+		}
+
+		if !valid && result.ErrorCode != ERROR_COUNTRY_MISMATCH {
+			t.Errorf("expected error code %v, got %v", ERROR_COUNTRY_MISMATCH, result.ErrorCode)
+		}
+	}
+}
+
+func TestConcurrentValidationsShareResult(t *testing.T) {
+	iban := "GB82WEST12345698765432"
+	c.Delete(cacheKey(iban, map[string]bool{}))
+
+	var wg sync.WaitGroup
+	results := make([]string, 10)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = validate(context.Background(), iban, map[string]bool{})
+		}(i)
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		if result == "" || result != results[0] {
+			t.Errorf("expected all validations to return the same result, got %v", result)
+		}
+	}
+}
+
+func TestRequireBICFailsWithoutBIC(t *testing.T) {
+	if db.Ping() != nil {
+		t.Skip("database is not available")
+	}
+
+	cases := map[string]bool{
+		"DE89370400440532013000": true,
+		// unknown bank code
+		"DE57000000000532013000": false,
+	}
+
+	for iban, valid := range cases {
+		res, err := http.Get(server.URL + "/validate/" + iban + "?getBIC=true&requireBIC=true")
+		if err != nil {
+			t.Errorf("failed to validate %v", err)
+			t.FailNow()
+		}
+
+		var result ValidationResponse
+		json.NewDecoder(res.Body).Decode(&result)
+		res.Body.Close()
+
+		if result.Valid != valid {
+			t.Errorf("expected valid to be %v for %v", valid, iban)
+		}
+
+		if !valid && result.ErrorCode != ERROR_BIC_NOT_FOUND {
+			t.Errorf("expected error code %v, got %v", ERROR_BIC_NOT_FOUND, result.ErrorCode)
+		}
+	}
+}
+
+func TestMissingBICIsValidByDefault(t *testing.T) {
+	if db.Ping() != nil {
+		t.Skip("database is not available")
+	}
+
+	strRes, err := validate(context.Background(), "DE57000000000532013000", map[string]bool{"getBIC": true})
+	if err != nil {
+		t.Errorf("failed to validate %v", err)
+		t.FailNow()
+	}
+
+	var result ValidationResponse
+	json.Unmarshal([]byte(strRes), &result)
+
+	if !result.Valid || result.ErrorCode != "" {
+		t.Errorf("expected a missing BIC to be accepted, got %v", strRes)
+	}
+}
+
+func TestDefaultValidationFlags(t *testing.T) {
+	defer func(getBIC bool, validateBankCode bool) {
+		cfg.DefaultGetBIC = getBIC
+		cfg.DefaultValidateBankCode = validateBankCode
+	}(cfg.DefaultGetBIC, cfg.DefaultValidateBankCode)
+	cfg.DefaultGetBIC = true
+	cfg.DefaultValidateBankCode = true
+
+	cases := map[string]bool{
+		"/validate/DE89370400440532013000":                 true,
+		"/validate/DE89370400440532013000?getBIC=false":    false,
+		"/validate/DE89370400440532013000?getBIC=true":     true,
+		"/validate/DE89370400440532013000?getBIC=&other=1": false,
+	}
+
+	for url, getBIC := range cases {
+		config := validationConfig(httptest.NewRequest("GET", url, nil))
+
+		if config["getBIC"] != getBIC || !config["validateBankCode"] {
+			t.Errorf("unexpected flags of %v: %v", url, config)
+		}
+	}
+
+	explicit := false
+	if boolOrDefault(nil, true) != true || boolOrDefault(&explicit, true) != false {
+		t.Errorf("expected omitted POST flags to use the default")
+	}
+}
+
 // code taken from
 // http://stackoverflow.com/a/18479916/1408463
 func readLines(path string) ([]string, error) {
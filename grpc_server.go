@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+
+	"github.com/fourcube/goiban"
+	"github.com/fourcube/goiban-service/goibanpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// grpcServer implements the Validate and Calculate RPCs on top of the
+// validation of the REST endpoints, so both share the cache and metrics
+type grpcServer struct {
+	goibanpb.UnimplementedGoibanServer
+}
+
+// Starts the gRPC server on the port. The returned server is stopped
+// gracefully on shutdown.
+func startGRPC(bindAddr string, port string) (*grpc.Server, error) {
+	listener, err := net.Listen("tcp", net.JoinHostPort(bindAddr, port))
+	if err != nil {
+		return nil, err
+	}
+
+	server := grpc.NewServer(grpc.UnaryInterceptor(grpcAPIKeyInterceptor))
+	goibanpb.RegisterGoibanServer(server, grpcServer{})
+
+	go func() {
+		err := server.Serve(listener)
+		if err != nil {
+			log.Fatal("Serve gRPC: ", err)
+		}
+	}()
+
+	log.Printf("Serving gRPC on %v", listener.Addr())
+	return server, nil
+}
+
+// Requires one of the configured API keys in the x-api-key metadata of
+// every call, like requireAPIKey does for the REST endpoints. In maintenance
+// mode all calls fail with UNAVAILABLE.
+func grpcAPIKeyInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if settings().Maintenance {
+		return nil, status.Error(codes.Unavailable, MAINTENANCE_MESSAGE)
+	}
+
+	if len(cfg.APIKeys) == 0 {
+		return handler(ctx, req)
+	}
+
+	key := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("x-api-key")) > 0 {
+		key = md.Get("x-api-key")[0]
+	}
+
+	label, ok := lookupAPIKey(key)
+	if !ok {
+		log.Printf("Rejected gRPC call to %v: missing or invalid API key", info.FullMethod)
+		return nil, status.Error(codes.Unauthenticated, "Missing or invalid API key.")
+	}
+
+	return handler(context.WithValue(ctx, API_KEY_LABEL, label), req)
+}
+
+func (grpcServer) Validate(ctx context.Context, req *goibanpb.ValidateRequest) (*goibanpb.ValidateResponse, error) {
+	if len(req.Iban) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "Empty request.")
+	}
+
+	if exceedsMaxLength(req.Iban) {
+		return nil, status.Error(codes.InvalidArgument, fmt.Sprintf("Input exceeds the maximum length of %d characters.", cfg.MaxIbanLength))
+	}
+
+	expectedCountry, ok := parseExpectedCountry(req.ExpectedCountry)
+	if !ok {
+		return nil, status.Error(codes.InvalidArgument, "Expected a two letter country code as expectedCountry.")
+	}
+
+	defaults := settings()
+	config := map[string]bool{
+		"validateBankCode":         boolOrDefault(req.ValidateBankCode, defaults.DefaultValidateBankCode),
+		"getBIC":                   boolOrDefault(req.GetBic, defaults.DefaultGetBIC),
+		"validateNationalChecksum": req.ValidateNationalChecksum,
+		"includeBankAddress":       req.IncludeBankAddress,
+		"requireBIC":               req.RequireBic,
+	}
+
+	strRes, err := validate(ctx, req.Iban, config)
+	if err != nil {
+		return nil, grpcLookupError(err)
+	}
+
+	var result ValidationResponse
+	json.Unmarshal([]byte(checkExpectedCountry(strRes, expectedCountry)), &result)
+
+	return &goibanpb.ValidateResponse{
+		Valid:    result.Valid,
+		Messages: result.Messages,
+		Iban:     result.Iban,
+		BankData: &goibanpb.BankData{
+			BankCode: result.BankData.BankCode,
+			Name:     result.BankData.Name,
+			Zip:      result.BankData.Zip,
+			City:     result.BankData.City,
+			Bic:      result.BankData.Bic,
+		},
+		CheckResults:     result.CheckResults,
+		ErrorCode:        result.ErrorCode,
+		CountryName:      result.CountryName,
+		NationalChecksum: result.NationalChecksum,
+	}, nil
+}
+
+func (grpcServer) Calculate(ctx context.Context, req *goibanpb.CalculateRequest) (*goibanpb.CalculateResponse, error) {
+	result := goiban.CalculateIBAN(req.CountryCode, req.BankCode, req.AccountNumber)
+	if !result.Valid {
+		return &goibanpb.CalculateResponse{Valid: false, ErrorCode: ERROR_CALCULATION_FAILED, Message: result.Message}, nil
+	}
+
+	response := &goibanpb.CalculateResponse{Valid: true, Iban: result.Data}
+	if boolOrDefault(req.GetBic, settings().DefaultGetBIC) {
+		response.Bic, response.Message = calculatedBic(ctx, result.Data)
+	}
+
+	return response, nil
+}
+
+// Returns the gRPC status of a failed bank data lookup, the codes
+// correspond to the HTTP status codes of lookupError
+func grpcLookupError(err error) error {
+	result, _ := lookupError(err, "")
+	message := result.Messages[0]
+
+	switch err {
+	case errDBTimeout:
+		return status.Error(codes.DeadlineExceeded, message)
+	case errDBSchema:
+		return status.Error(codes.Internal, message)
+	case errDBBusy:
+		return status.Error(codes.ResourceExhausted, message)
+	}
+
+	return status.Error(codes.Unavailable, message)
+}
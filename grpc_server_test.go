@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fourcube/goiban-service/goibanpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCValidate(t *testing.T) {
+	res, err := grpcServer{}.Validate(context.Background(), &goibanpb.ValidateRequest{Iban: "DE89370400440532013000"})
+	if err != nil {
+		t.Errorf("failed to validate %v", err)
+		t.FailNow()
+	}
+
+	if !res.Valid || res.Iban != "DE89370400440532013000" || res.ErrorCode != "" {
+		t.Errorf("unexpected result %v", res)
+	}
+
+	res, err = grpcServer{}.Validate(context.Background(), &goibanpb.ValidateRequest{Iban: "DE89370400440532013001"})
+	if err != nil {
+		t.Errorf("failed to validate %v", err)
+		t.FailNow()
+	}
+
+	if res.Valid || res.ErrorCode != ERROR_BAD_CHECKSUM {
+		t.Errorf("expected a bad checksum, got %v", res)
+	}
+}
+
+func TestGRPCValidateExpectedCountry(t *testing.T) {
+	res, err := grpcServer{}.Validate(context.Background(), &goibanpb.ValidateRequest{Iban: "DE89370400440532013000", ExpectedCountry: "at"})
+	if err != nil {
+		t.Errorf("failed to validate %v", err)
+		t.FailNow()
+	}
+
+	if res.Valid || res.ErrorCode != ERROR_COUNTRY_MISMATCH {
+		t.Errorf("expected a country mismatch, got %v", res)
+	}
+}
+
+func TestGRPCValidateRejectsInvalidArguments(t *testing.T) {
+	for _, req := range []*goibanpb.ValidateRequest{
+		{Iban: ""},
+		{Iban: "DE89370400440532013000", ExpectedCountry: "Germany"},
+	} {
+		_, err := grpcServer{}.Validate(context.Background(), req)
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("expected InvalidArgument for %v, got %v", req, err)
+		}
+	}
+}
+
+func TestGRPCCalculate(t *testing.T) {
+	res, err := grpcServer{}.Calculate(context.Background(), &goibanpb.CalculateRequest{CountryCode: "DE", BankCode: "37040044", AccountNumber: "0532013000"})
+	if err != nil {
+		t.Errorf("failed to calculate %v", err)
+		t.FailNow()
+	}
+
+	if !res.Valid || res.Iban != "DE89370400440532013000" {
+		t.Errorf("unexpected result %v", res)
+	}
+}
+
+func TestGRPCRequiresAPIKey(t *testing.T) {
+	defer func(keys map[string]string) { cfg.APIKeys = keys }(cfg.APIKeys)
+	cfg.APIKeys = map[string]string{"secret": "partner"}
+
+	info := &grpc.UnaryServerInfo{FullMethod: goibanpb.Goiban_Validate_FullMethodName}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return apiKeyLabelOf(ctx), nil
+	}
+
+	_, err := grpcAPIKeyInterceptor(context.Background(), nil, info, handler)
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated without a key, got %v", err)
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.New(map[string]string{"x-api-key": "secret"}))
+	label, err := grpcAPIKeyInterceptor(ctx, nil, info, handler)
+	if err != nil || label != "partner" {
+		t.Errorf("expected the call to pass with the label of the key, got %v %v", label, err)
+	}
+}
+
+func apiKeyLabelOf(ctx context.Context) string {
+	label, _ := ctx.Value(API_KEY_LABEL).(string)
+	return label
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// GZIP_MIN_SIZE is the minimum response size in bytes that gets compressed
+const GZIP_MIN_SIZE = 1024
+
+var gzipWriters = sync.Pool{
+	New: func() interface{} {
+		return gzip.NewWriter(nil)
+	},
+}
+
+// Compresses the responses of h with gzip when the client accepts it.
+// Responses smaller than GZIP_MIN_SIZE are sent uncompressed.
+func gzipHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "HEAD" || !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		gw := &gzipResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		defer gw.close()
+
+		h.ServeHTTP(gw, r)
+	})
+}
+
+// gzipResponseWriter buffers the response until GZIP_MIN_SIZE bytes have
+// been written, then it switches to compressing the response.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	buf         []byte
+	gz          *gzip.Writer
+}
+
+func (gw *gzipResponseWriter) WriteHeader(status int) {
+	if gw.wroteHeader {
+		return
+	}
+
+	gw.status = status
+	gw.wroteHeader = true
+}
+
+func (gw *gzipResponseWriter) Write(p []byte) (int, error) {
+	gw.wroteHeader = true
+
+	if gw.gz != nil {
+		return gw.gz.Write(p)
+	}
+
+	gw.buf = append(gw.buf, p...)
+	if len(gw.buf) >= GZIP_MIN_SIZE {
+		err := gw.startCompression()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// Flush starts the compression regardless of the response size, so that
+// streamed responses reach the client immediately.
+func (gw *gzipResponseWriter) Flush() {
+	if gw.gz == nil {
+		gw.startCompression()
+	}
+
+	gw.gz.Flush()
+	if flusher, ok := gw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (gw *gzipResponseWriter) startCompression() error {
+	header := gw.ResponseWriter.Header()
+
+	// the length set by the handler is the uncompressed length
+	header.Del("Content-Length")
+	header.Set("Content-Encoding", "gzip")
+	gw.ResponseWriter.WriteHeader(gw.status)
+
+	gw.gz = gzipWriters.Get().(*gzip.Writer)
+	gw.gz.Reset(gw.ResponseWriter)
+
+	_, err := gw.gz.Write(gw.buf)
+	gw.buf = nil
+	return err
+}
+
+func (gw *gzipResponseWriter) close() {
+	if gw.gz != nil {
+		gw.gz.Close()
+		gzipWriters.Put(gw.gz)
+		return
+	}
+
+	// the response is too small, send it as it is
+	if gw.wroteHeader {
+		gw.ResponseWriter.WriteHeader(gw.status)
+		gw.ResponseWriter.Write(gw.buf)
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func gzipTestHandler(body string) http.Handler {
+	return gzipHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(body))
+	}))
+}
+
+func TestGzipCompressesLargeResponses(t *testing.T) {
+	body := strings.Repeat("DE89370400440532013000", 100)
+
+	req := httptest.NewRequest("GET", "/validate/batch", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	gzipTestHandler(body).ServeHTTP(w, req)
+
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected gzip content encoding")
+	}
+
+	if w.Header().Get("Content-Length") != "" {
+		t.Errorf("expected the uncompressed Content-Length to be removed")
+	}
+
+	reader, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("failed to read gzip response %v", err)
+	}
+
+	data, _ := ioutil.ReadAll(reader)
+	if string(data) != body {
+		t.Errorf("unexpected uncompressed body")
+	}
+}
+
+func TestGzipSkipsSmallResponses(t *testing.T) {
+	req := httptest.NewRequest("GET", "/validate/DE89370400440532013000", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	gzipTestHandler(`{"valid":true}`).ServeHTTP(w, req)
+
+	if w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("expected small response to be uncompressed")
+	}
+
+	if w.Body.String() != `{"valid":true}` || w.Header().Get("Content-Length") != "14" {
+		t.Errorf("unexpected response %v", w.Body.String())
+	}
+}
+
+func TestGzipRequiresAcceptEncoding(t *testing.T) {
+	w := httptest.NewRecorder()
+	gzipTestHandler(strings.Repeat("a", 2*GZIP_MIN_SIZE)).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("expected uncompressed response")
+	}
+}
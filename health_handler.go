@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// READY_TIMEOUT is the maximum time the readiness check waits for the database
+const READY_TIMEOUT = 2 * time.Second
+
+// Processes requests to the /health url. Reports that the process is alive
+// without touching the database.
+func healthHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	w.Header().Add("Content-Type", "application/json; charset=utf-8")
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"status":"ok"}`))
+}
+
+// Processes requests to the /ready url. Reports whether the database can be
+// reached. In maintenance mode the service is not ready, so no traffic is
+// routed to it, while /health still reports it as alive.
+func readyHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	w.Header().Add("Content-Type", "application/json; charset=utf-8")
+
+	if settings().Maintenance {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"status":"maintenance"}`))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), READY_TIMEOUT)
+	defer cancel()
+
+	if db == nil || db.PingContext(ctx) != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"status":"unavailable"}`))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"status":"ok"}`))
+}
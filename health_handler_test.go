@@ -0,0 +1,28 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestHealthReportsOk(t *testing.T) {
+	resp, err := http.Get(server.URL + "/health")
+
+	if err != nil {
+		t.Errorf("failed to get health %v", err)
+		t.FailNow()
+	}
+
+	var res map[string]string
+	json.NewDecoder(resp.Body).Decode(&res)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status %v, got %v", http.StatusOK, resp.StatusCode)
+	}
+
+	if res["status"] != "ok" {
+		t.Errorf("expected status ok, got %v", res["status"])
+	}
+}
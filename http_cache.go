@@ -0,0 +1,73 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Writes a successful validation response with caching headers. Valid
+// results may be cached for cfg.HTTPCacheMaxAge, invalid ones for
+// cfg.HTTPCacheInvalidMaxAge. Requests whose If-None-Match header matches
+// the ETag are answered with HTTP 304. Only GET requests are cacheable.
+func writeCacheableResponse(w http.ResponseWriter, r *http.Request, data []byte, valid bool) {
+	maxAge := cfg.HTTPCacheMaxAge.Duration
+	if !valid {
+		maxAge = cfg.HTTPCacheInvalidMaxAge.Duration
+	}
+
+	if r.Method != "GET" || maxAge <= 0 {
+		writeResponse(w, r, http.StatusOK, data)
+		return
+	}
+
+	// responses to API key holders must not be served from shared caches
+	visibility := "public"
+	if len(cfg.APIKeys) > 0 {
+		visibility = "private"
+	}
+
+	etag := responseETag(r, data)
+	header := w.Header()
+	header.Set("Cache-Control", visibility+", max-age="+strconv.Itoa(int(maxAge.Seconds())))
+	header.Set("ETag", etag)
+	header.Add("Vary", "Accept, Accept-Language")
+
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, data)
+}
+
+// Returns a weak ETag of the response. The format, language and selected
+// fields are included since writeResponse converts data accordingly, and
+// weak since the response may be compressed.
+func responseETag(r *http.Request, data []byte) string {
+	hash := sha256.New()
+	hash.Write(data)
+	hash.Write([]byte(responseFormat(r) + messageLanguage(r)))
+	hash.Write([]byte(r.URL.Query().Get("fields") + "|" + r.URL.Query().Get("exclude")))
+
+	return `W/"` + hex.EncodeToString(hash.Sum(nil))[:32] + `"`
+}
+
+// Reports whether the If-None-Match header contains the ETag, using the
+// weak comparison of RFC 7232
+func etagMatches(ifNoneMatch string, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
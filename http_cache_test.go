@@ -0,0 +1,81 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestValidationCacheHeaders(t *testing.T) {
+	cases := map[string]string{
+		"DE89370400440532013000": "public, max-age=3600",
+		"DE89370400440532013001": "public, max-age=300",
+	}
+
+	for iban, expected := range cases {
+		resp, err := http.Get(server.URL + "/validate/" + iban)
+		if err != nil {
+			t.Errorf("failed to validate %v", err)
+			t.FailNow()
+		}
+		resp.Body.Close()
+
+		if resp.Header.Get("Cache-Control") != expected || resp.Header.Get("ETag") == "" {
+			t.Errorf("expected %v for %v, got %v", expected, iban, resp.Header.Get("Cache-Control"))
+		}
+	}
+}
+
+func TestValidationIfNoneMatch(t *testing.T) {
+	resp, err := http.Get(server.URL + "/validate/DE89370400440532013000")
+	if err != nil {
+		t.Errorf("failed to validate %v", err)
+		t.FailNow()
+	}
+	resp.Body.Close()
+
+	etag := resp.Header.Get("ETag")
+	req, _ := http.NewRequest("GET", server.URL+"/validate/DE89370400440532013000", nil)
+	req.Header.Set("If-None-Match", etag)
+
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Errorf("failed to validate %v", err)
+		t.FailNow()
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotModified {
+		t.Errorf("expected HTTP 304 for %v, got %v", etag, resp.StatusCode)
+	}
+
+	// another representation has another ETag
+	req.Header.Set("Accept", "application/xml")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Errorf("failed to validate %v", err)
+		t.FailNow()
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected HTTP 200 for XML, got %v", resp.StatusCode)
+	}
+}
+
+func TestEtagMatches(t *testing.T) {
+	etag := `W/"abc"`
+	cases := map[string]bool{
+		"":             false,
+		`W/"abc"`:      true,
+		`"abc"`:        true,
+		`"x", W/"abc"`: true,
+		"*":            true,
+		`"abcd"`:       false,
+	}
+
+	for header, expected := range cases {
+		if etagMatches(header, etag) != expected {
+			t.Errorf("expected %v for %q", expected, header)
+		}
+	}
+}
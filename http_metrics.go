@@ -0,0 +1,94 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// ROUTE_UNMATCHED is the route of requests which match no route of the
+// router, like static files and unknown paths
+const ROUTE_UNMATCHED = "unmatched"
+
+// METHOD_OTHER is recorded for requests with a non-standard method
+const METHOD_OTHER = "OTHER"
+
+// standardMethods are the HTTP methods recorded as they are
+var standardMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodConnect: true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+// routeProbe replaces a path segment to find out whether the route has a
+// parameter there
+const routeProbe = "\x00"
+
+// Counts the requests to h and records their latency and status by method
+// and route template of the router, e.g. /validate/:iban. The templates
+// and methods keep the number of distinct metrics small, unlike the
+// concrete paths and the methods sent by clients.
+func httpMetricsHandler(router *httprouter.Router, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &accessLogResponseWriter{ResponseWriter: w, status: http.StatusOK}
+
+		h.ServeHTTP(sw, r)
+
+		method := metricsMethod(r.Method)
+		route := routeTemplate(router, r)
+		d := time.Since(start)
+		inmemMetrics.RegisterRequest(method, route, sw.status, d)
+		promMetrics.RegisterRequest(method, route, sw.status, d)
+	})
+}
+
+// Returns the method as recorded in the metrics, METHOD_OTHER for methods
+// outside of the standard ones
+func metricsMethod(method string) string {
+	if standardMethods[method] {
+		return method
+	}
+
+	return METHOD_OTHER
+}
+
+// Returns the template of the route the request matches. A segment is a
+// parameter of the route when the path still matches with the segment
+// replaced, it is named after the parameter then. Segments are never
+// compared to the values of the parameters, which may equal other segments.
+func routeTemplate(router *httprouter.Router, r *http.Request) string {
+	handle, params, _ := router.Lookup(r.Method, r.URL.Path)
+	if handle == nil {
+		return ROUTE_UNMATCHED
+	}
+
+	segments := strings.Split(r.URL.Path, "/")
+	template := make([]string, len(segments))
+	next := 0
+	for i, segment := range segments {
+		template[i] = segment
+		if segment == "" || next >= len(params) {
+			continue
+		}
+
+		segments[i] = routeProbe
+		_, probed, _ := router.Lookup(r.Method, strings.Join(segments, "/"))
+		segments[i] = segment
+
+		if len(probed) == len(params) && probed[next].Value == routeProbe {
+			template[i] = ":" + params[next].Key
+			next++
+		}
+	}
+
+	return strings.Join(template, "/")
+}
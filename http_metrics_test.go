@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestRouteTemplate(t *testing.T) {
+	router := httprouter.New()
+	router.GET("/validate/:iban", validationHandler)
+	router.GET("/calculate/:countryCode/:bankCode/:accountNumber", calculateIBAN)
+	router.GET("/health", healthHandler)
+
+	cases := map[string]string{
+		"/validate/DE89370400440532013000":  "/validate/:iban",
+		"/calculate/DE/37040044/0532013000": "/calculate/:countryCode/:bankCode/:accountNumber",
+		"/calculate/DE/00/00":               "/calculate/:countryCode/:bankCode/:accountNumber",
+		"/validate/validate":                "/validate/:iban",
+		"/calculate/calculate/00/calculate": "/calculate/:countryCode/:bankCode/:accountNumber",
+		"/health":                           "/health",
+		"/index.html":                       ROUTE_UNMATCHED,
+	}
+
+	for path, expected := range cases {
+		route := routeTemplate(router, httptest.NewRequest("GET", path, nil))
+		if route != expected {
+			t.Errorf("expected route %v for %v, got %v", expected, path, route)
+		}
+	}
+}
+
+func TestMetricsMethod(t *testing.T) {
+	cases := map[string]string{
+		"GET":     "GET",
+		"OPTIONS": "OPTIONS",
+		"get":     METHOD_OTHER,
+		"FOOBAR":  METHOD_OTHER,
+	}
+
+	for method, expected := range cases {
+		if recorded := metricsMethod(method); recorded != expected {
+			t.Errorf("expected method %v to be recorded as %v, got %v", method, expected, recorded)
+		}
+	}
+}
+
+func TestHTTPMetricsKeepStatus(t *testing.T) {
+	router := httprouter.New()
+	router.GET("/validate/:iban", validationHandler)
+	handler := httpMetricsHandler(router, router)
+
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, httptest.NewRequest("GET", "/validate/DE89370400440532013000?getBIC=false&validateBankCode=false", nil))
+
+	if resp.Code != http.StatusOK {
+		t.Errorf("expected the response to be passed on, got %v", resp.Code)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/fourcube/goiban"
+	"github.com/julienschmidt/httprouter"
+)
+
+// FormatResult holds the presentation forms of an IBAN
+type FormatResult struct {
+	Valid      bool   `json:"valid"`
+	Electronic string `json:"electronic"`
+	Print      string `json:"print"`
+}
+
+// Processes requests to the /format/ url. Only the structure of the IBAN
+// is validated, the bank data is not looked up.
+func formatHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Allow CORS
+	allowOrigin(w, r)
+
+	input := ps.ByName("iban")
+	if exceedsMaxLength(input) {
+		writeTooLong(w, r)
+		return
+	}
+
+	iban := normalizeIban(input)
+	if len(iban) == 0 {
+		res, _ := json.MarshalIndent(errorResult(ERROR_EMPTY_INPUT, "Empty request.", input), "", "  ")
+		writeResponse(w, r, http.StatusBadRequest, res)
+		return
+	}
+
+	parserResult := goiban.IsParseable(iban)
+	if !parserResult.Valid {
+		res, _ := json.MarshalIndent(errorResult(ERROR_NOT_PARSEABLE, "Cannot parse as IBAN: "+parserResult.Message, input), "", "  ")
+		writeResponse(w, r, http.StatusBadRequest, res)
+		return
+	}
+
+	lengthResult := validateLength(goiban.ExtractCountryCode(iban), iban)
+	if lengthResult != nil {
+		lengthResult.Iban = input
+		res, _ := json.MarshalIndent(ValidationResponse{ValidationResult: lengthResult, ErrorCode: ERROR_BAD_LENGTH}, "", "  ")
+		writeResponse(w, r, http.StatusBadRequest, res)
+		return
+	}
+
+	data, err := json.MarshalIndent(FormatResult{true, iban, printFormat(iban)}, "", "  ")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, data)
+}
+
+// Returns the print form of an IBAN in electronic form, which is
+// divided into groups of four characters
+func printFormat(iban string) string {
+	var b strings.Builder
+	for i, r := range iban {
+		if i > 0 && i%4 == 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
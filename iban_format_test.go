@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	resp, err := http.Get(server.URL + "/format/" + url.PathEscape("de89 370400440532013000"))
+
+	if err != nil {
+		t.Errorf("failed to format iban %v", err)
+		t.FailNow()
+	}
+
+	var res FormatResult
+	json.NewDecoder(resp.Body).Decode(&res)
+	resp.Body.Close()
+
+	if res.Electronic != "DE89370400440532013000" {
+		t.Errorf("unexpected electronic form %v", res.Electronic)
+	}
+
+	if res.Print != "DE89 3704 0044 0532 0130 00" {
+		t.Errorf("unexpected print form %v", res.Print)
+	}
+}
+
+func TestFormatRejectsInvalidStructure(t *testing.T) {
+	for _, iban := range []string{"D", "DE8937040044053201300"} {
+		resp, err := http.Get(server.URL + "/format/" + iban)
+
+		if err != nil {
+			t.Errorf("failed to format iban %v", err)
+			t.FailNow()
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("expected status %v for %v, got %v", http.StatusBadRequest, iban, resp.StatusCode)
+		}
+	}
+}
@@ -1,39 +1,66 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"github.com/fourcube/goiban"
 	"github.com/julienschmidt/httprouter"
 )
 
+// CALCULATION_COUNTRIES are the countries for which goiban.CalculateIBAN
+// implements the construction of an IBAN from bank code and account number
+var CALCULATION_COUNTRIES = []string{"BE", "DE"}
+
 type CalculateSuccess struct {
-	Valid bool   `json:"valid"`
-	IBAN  string `json:"iban"`
+	Valid   bool   `json:"valid"`
+	IBAN    string `json:"iban"`
+	Bic     string `json:"bic,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// CalculateCheck is returned with ?validateOnly=true. It reports whether
+// the inputs produce a valid IBAN without returning the IBAN itself.
+type CalculateCheck struct {
+	Valid     bool     `json:"valid"`
+	ErrorCode string   `json:"errorCode,omitempty"`
+	Messages  []string `json:"messages"`
 }
 
 type CalculateError struct {
-	Valid   bool   `json:"valid"`
-	Message string `json:"message"`
+	Valid     bool   `json:"valid"`
+	ErrorCode string `json:"errorCode"`
+	Message   string `json:"message"`
 }
 
 func calculateIBAN(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	w.Header().Add("Content-Type", "application/json; charset=utf-8")
 	// Allow CORS
-	w.Header().Set("Access-Control-Allow-Origin", "*")
+	allowOrigin(w, r)
 
 	result := goiban.CalculateIBAN(
 		ps.ByName("countryCode"),
 		ps.ByName("bankCode"),
 		ps.ByName("accountNumber"))
 
+	if toBoolean(r.FormValue("validateOnly")) {
+		writeCalculationCheck(w, r, result.Valid, result.Data, result.Message)
+		return
+	}
+
 	var data []byte
 	var err error
 	if result.Valid {
-		data, err = json.Marshal(CalculateSuccess{true, result.Data})
+		success := CalculateSuccess{Valid: true, IBAN: result.Data}
+		if boolParam(r, "getBIC", settings().DefaultGetBIC) {
+			success.Bic, success.Message = calculatedBic(r.Context(), result.Data)
+		}
+
+		data, err = json.Marshal(success)
 	} else {
-		data, err = json.Marshal(CalculateError{false, result.Message})
+		data, err = json.Marshal(CalculateError{false, ERROR_CALCULATION_FAILED, result.Message})
 	}
 
 	if err != nil {
@@ -42,22 +69,154 @@ func calculateIBAN(w http.ResponseWriter, r *http.Request, ps httprouter.Params)
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	w.Write(data)
+	writeResponse(w, r, http.StatusOK, data)
+}
+
+// Processes requests to the /calculate url. Takes the countryCode, bankCode
+// and accountNumber as query parameters, so they are passed on exactly
+// (e.g. with leading zeros), and answers like /calculate/{countryCode}/...
+func calculateIBANFromQuery(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	countryCode := r.FormValue("countryCode")
+	bankCode := r.FormValue("bankCode")
+	accountNumber := r.FormValue("accountNumber")
+
+	if countryCode == "" || bankCode == "" || accountNumber == "" {
+		// Allow CORS
+		allowOrigin(w, r)
+
+		writeError(w, r, http.StatusBadRequest, ERROR_INVALID_REQUEST, "Expected the countryCode, bankCode and accountNumber parameters.")
+		return
+	}
+
+	calculateIBAN(w, r, httprouter.Params{
+		{Key: "countryCode", Value: countryCode},
+		{Key: "bankCode", Value: bankCode},
+		{Key: "accountNumber", Value: accountNumber},
+	})
+}
+
+// Writes the result of the format, length and checksum checks of a
+// calculated IBAN. The IBAN is not part of the response.
+func writeCalculationCheck(w http.ResponseWriter, r *http.Request, calculated bool, iban string, message string) {
+	check := CalculateCheck{Valid: false, ErrorCode: ERROR_CALCULATION_FAILED, Messages: []string{message}}
+
+	if calculated {
+		check = checkCalculatedIBAN(iban)
+	}
+
+	data, err := json.Marshal(check)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, data)
+}
+
+// Runs the structure, length, BBAN format and checksum checks of the
+// validation on a calculated IBAN
+func checkCalculatedIBAN(iban string) CalculateCheck {
+	parserResult := goiban.IsParseable(iban)
+	if !parserResult.Valid {
+		return CalculateCheck{false, ERROR_NOT_PARSEABLE, []string{"Cannot parse as IBAN: " + parserResult.Message}}
+	}
+
+	parsedIban := goiban.ParseToIban(iban)
+	if result := validateLength(parsedIban.GetCountryCode(), iban); result != nil {
+		return CalculateCheck{false, ERROR_BAD_LENGTH, result.Messages}
+	}
+
+	if result := validateBbanFormat(parsedIban.GetCountryCode(), iban); result != nil {
+		return CalculateCheck{false, ERROR_STRUCTURE_MISMATCH, result.Messages}
+	}
+
+	result := parsedIban.Validate()
+	return CalculateCheck{result.Valid, validationErrorCode(result), result.Messages}
+}
+
+// Looks up the BIC of the bank of a calculated IBAN. A missing BIC does not
+// fail the calculation, a message explaining why it is empty is returned instead.
+func calculatedBic(ctx context.Context, iban string) (bic string, message string) {
+	if dbBreaker.allow() != nil {
+		return "", "Bank data is currently unavailable."
+	}
+
+	slot, err := acquireDBSlot()
+	if err != nil {
+		dbBreaker.record(err)
+		return "", "Too many concurrent bank data lookups."
+	}
+	defer slot.release()
+
+	err = withDBRetry(db.Ping)
+	if err != nil {
+		dbBreaker.record(err)
+		return "", "Bank data is currently unavailable."
+	}
+
+	if checkSchema(ctx) != nil {
+		dbBreaker.record(errDBSchema)
+		return "", "Bank data table is missing."
+	}
+
+	promMetrics.RegisterLookup("bic")
+	_, span := startLookupSpan(ctx, "bic")
+	start := time.Now()
+	result, err := withLookupTimeout(ctx, slot, goiban.NewValidationResult(true, "", iban), func(result *goiban.ValidationResult) *goiban.ValidationResult {
+		return goiban.GetBic(goiban.ParseToIban(iban), result, db)
+	})
+	registerLatency("bic", start)
+	endSpan(span, err)
+	dbBreaker.record(err)
+
+	if err != nil {
+		return "", "Bank data lookup timed out."
+	}
+
+	if result.BankData.Bic == "" {
+		return "", "No BIC found for the bank code."
+	}
+
+	return result.BankData.Bic, ""
+}
+
+// Processes requests to the /calculate/countries url. The route shares its
+// wildcard with /calculate/:countryCode/..., other values are not found.
+func calculationCountriesHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if ps.ByName("countryCode") != "countries" {
+		notFoundHandler(w, r)
+		return
+	}
+
+	// Allow CORS
+	allowOrigin(w, r)
+
+	data, err := json.Marshal(CALCULATION_COUNTRIES)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, data)
 }
 
 func calculateAndValidateIBAN(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	w.Header().Add("Content-Type", "application/json; charset=utf-8")
 	// Allow CORS
-	w.Header().Set("Access-Control-Allow-Origin", "*")
+	allowOrigin(w, r)
 
 	iban := goiban.CalculateIBAN(
 		ps.ByName("countryCode"),
 		ps.ByName("bankCode"),
 		ps.ByName("accountNumber"))
 
+	if toBoolean(r.FormValue("validateOnly")) {
+		writeCalculationCheck(w, r, iban.Valid, iban.Data, iban.Message)
+		return
+	}
+
 	if !iban.Valid {
-		data, err := json.Marshal(CalculateError{false, iban.Message})
+		data, err := json.Marshal(CalculateError{false, ERROR_CALCULATION_FAILED, iban.Message})
 
 		if err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
@@ -65,8 +224,7 @@ func calculateAndValidateIBAN(w http.ResponseWriter, r *http.Request, ps httprou
 			return
 		}
 
-		w.WriteHeader(http.StatusOK)
-		w.Write(data)
+		writeResponse(w, r, http.StatusOK, data)
 		r.Body.Close()
 		return
 	}
@@ -138,3 +138,138 @@ func TestGenerateIBANV2GetBIC(t *testing.T) {
 		t.Errorf("expected request to succeed")
 	}
 }
+
+func TestGenerateIBANGetBIC(t *testing.T) {
+	resp, err := http.Get(server.URL + "/calculate/DE/37040044/0532013000?getBIC=true")
+
+	if err != nil {
+		t.Errorf("failed to generate iban %v", err)
+		t.FailNow()
+	}
+
+	var res CalculateSuccess
+	data, _ := ioutil.ReadAll(resp.Body)
+	json.Unmarshal(data, &res)
+
+	if !res.Valid || res.IBAN != "DE89370400440532013000" {
+		t.Errorf("expected the calculation to succeed even without a BIC, was %v", res)
+	}
+
+	if res.Bic == "" && res.Message == "" {
+		t.Errorf("expected a message explaining the missing BIC")
+	}
+}
+
+func TestCalculationCountries(t *testing.T) {
+	resp, err := http.Get(server.URL + "/calculate/countries")
+
+	if err != nil {
+		t.Errorf("failed to list countries %v", err)
+		t.FailNow()
+	}
+
+	var countries []string
+	data, _ := ioutil.ReadAll(resp.Body)
+	json.Unmarshal(data, &countries)
+
+	if len(countries) != len(CALCULATION_COUNTRIES) {
+		t.Errorf("unexpected countries %v", countries)
+	}
+
+	// every listed country must actually be calculated
+	samples := map[string][]string{
+		"BE": {"539", "007547034"},
+		"DE": {"37040044", "0532013000"},
+	}
+
+	for _, countryCode := range countries {
+		sample, ok := samples[countryCode]
+		if !ok {
+			t.Errorf("missing sample data for %v", countryCode)
+			continue
+		}
+
+		if result := goiban.CalculateIBAN(countryCode, sample[0], sample[1]); !result.Valid {
+			t.Errorf("expected %v to be calculated: %v", countryCode, result.Message)
+		}
+	}
+}
+
+func TestCalculateValidateOnlyOmitsIBAN(t *testing.T) {
+	for _, url := range []string{"/calculate/BE/539/007547034?validateOnly=true", "/v2/calculate/BE/539/007547034?validateOnly=true"} {
+		resp, err := http.Get(server.URL + url)
+
+		if err != nil {
+			t.Errorf("failed to check the calculation %v", err)
+			t.FailNow()
+		}
+
+		data, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		var res map[string]interface{}
+		json.Unmarshal(data, &res)
+
+		if res["valid"] != true {
+			t.Errorf("expected %v to be valid, got %s", url, data)
+		}
+
+		if _, ok := res["iban"]; ok {
+			t.Errorf("expected %v to omit the IBAN, got %s", url, data)
+		}
+	}
+}
+
+func TestCalculateValidateOnlyInvalidInput(t *testing.T) {
+	resp, err := http.Get(server.URL + "/calculate/12/539/007547034?validateOnly=true")
+
+	if err != nil {
+		t.Errorf("failed to check the calculation %v", err)
+		t.FailNow()
+	}
+
+	var res CalculateCheck
+	json.NewDecoder(resp.Body).Decode(&res)
+
+	if res.Valid || res.ErrorCode != ERROR_CALCULATION_FAILED || len(res.Messages) != 1 {
+		t.Errorf("expected the calculation to fail, got %v", res)
+	}
+}
+
+func TestCalculateFromQueryParameters(t *testing.T) {
+	fromPath, err := http.Get(server.URL + "/calculate/BE/539/007547034")
+	if err != nil {
+		t.Errorf("failed to generate iban %v", err)
+		t.FailNow()
+	}
+	expected, _ := ioutil.ReadAll(fromPath.Body)
+	fromPath.Body.Close()
+
+	fromQuery, err := http.Get(server.URL + "/calculate?countryCode=BE&bankCode=539&accountNumber=007547034")
+	if err != nil {
+		t.Errorf("failed to generate iban %v", err)
+		t.FailNow()
+	}
+	data, _ := ioutil.ReadAll(fromQuery.Body)
+	fromQuery.Body.Close()
+
+	if string(data) != string(expected) {
+		t.Errorf("expected %s, got %s", expected, data)
+	}
+}
+
+func TestCalculateFromQueryParametersMissing(t *testing.T) {
+	resp, err := http.Get(server.URL + "/calculate?countryCode=BE&bankCode=539")
+	if err != nil {
+		t.Errorf("failed to generate iban %v", err)
+		t.FailNow()
+	}
+
+	var res ErrorResponse
+	json.NewDecoder(resp.Body).Decode(&res)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest || res.ErrorCode != ERROR_INVALID_REQUEST {
+		t.Errorf("expected the request to be rejected, got %v %v", resp.StatusCode, res)
+	}
+}
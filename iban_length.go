@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/fourcube/goiban"
+)
+
+// MAX_IBAN_LENGTH is the maximum length of an IBAN in any country
+const MAX_IBAN_LENGTH = 34
+
+// IBAN_LENGTHS maps country codes to the IBAN length defined in the
+// ISO 13616 registry. The French overseas territories use the format of FR.
+var IBAN_LENGTHS = map[string]int{
+	"AD": 24, "AE": 23, "AL": 28, "AT": 20, "AZ": 28, "BA": 20, "BE": 16, "BG": 22,
+	"BH": 22, "BI": 27, "BL": 27, "BR": 29, "BY": 28, "CH": 21, "CR": 22, "CY": 28,
+	"CZ": 24, "DE": 22, "DJ": 27, "DK": 18, "DO": 28, "EE": 20, "EG": 29, "ES": 24,
+	"FI": 18, "FK": 18, "FO": 18, "FR": 27, "GB": 22, "GE": 22, "GF": 27, "GI": 23,
+	"GL": 18, "GP": 27, "GR": 27, "GT": 28, "HR": 21, "HU": 28, "IE": 22, "IL": 23,
+	"IQ": 23, "IS": 26, "IT": 27, "JO": 30, "KW": 30, "KZ": 20, "LB": 28, "LC": 32,
+	"LI": 21, "LT": 20, "LU": 20, "LV": 21, "LY": 25, "MC": 27, "MD": 24, "ME": 22,
+	"MF": 27, "MK": 19, "MN": 20, "MQ": 27, "MR": 27, "MT": 31, "MU": 30, "NC": 27,
+	"NI": 28, "NL": 18, "NO": 15, "OM": 23, "PF": 27, "PK": 24, "PL": 28, "PM": 27,
+	"PS": 29, "PT": 25, "QA": 29, "RE": 27, "RO": 24, "RS": 22, "RU": 33, "SA": 24,
+	"SC": 31, "SD": 18, "SE": 24, "SI": 19, "SK": 24, "SM": 27, "SO": 23, "ST": 25,
+	"SV": 28, "TF": 27, "TL": 23, "TN": 24, "TR": 26, "UA": 29, "VA": 22, "VG": 24,
+	"WF": 27, "XK": 20, "YE": 30, "YT": 27,
+}
+
+// Checks the length of iban against the registry length of its country.
+// Returns a failed result when the length doesn't match, nil otherwise.
+// IBANs of countries missing from the registry are not checked.
+func validateLength(countryCode string, iban string) *goiban.ValidationResult {
+	expected, ok := IBAN_LENGTHS[countryCode]
+
+	if !ok || len(iban) == expected {
+		return nil
+	}
+
+	message := fmt.Sprintf("Expected %d characters for %v, got %d", expected, countryCode, len(iban))
+	return goiban.NewValidationResult(false, message, iban)
+}
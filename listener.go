@@ -0,0 +1,36 @@
+package main
+
+import (
+	"net"
+	"os"
+	"strings"
+)
+
+// UNIX_SOCKET_PREFIX marks a port argument as the path of a Unix socket
+const UNIX_SOCKET_PREFIX = "unix:"
+
+// Returns the path of the Unix socket if port has the form unix:/path/to/sock
+func unixSocketPath(port string) (string, bool) {
+	if !strings.HasPrefix(port, UNIX_SOCKET_PREFIX) {
+		return "", false
+	}
+
+	return strings.TrimPrefix(port, UNIX_SOCKET_PREFIX), true
+}
+
+// Listens on the Unix socket given as unix:/path/to/sock, or on the TCP
+// port at bindAddr. A socket file left behind by a crashed process is
+// removed first. The socket file is removed again when the listener is
+// closed on shutdown.
+func newListener(bindAddr string, port string) (net.Listener, error) {
+	path, ok := unixSocketPath(port)
+	if !ok {
+		return net.Listen("tcp", net.JoinHostPort(bindAddr, port))
+	}
+
+	if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		os.Remove(path)
+	}
+
+	return net.Listen("unix", path)
+}
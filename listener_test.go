@@ -0,0 +1,50 @@
+package main
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUnixSocketPath(t *testing.T) {
+	if path, ok := unixSocketPath("unix:/var/run/goiban.sock"); !ok || path != "/var/run/goiban.sock" {
+		t.Errorf("expected a socket path, got %v", path)
+	}
+
+	if _, ok := unixSocketPath("8080"); ok {
+		t.Errorf("expected a TCP port")
+	}
+}
+
+func TestUnixSocketIsRemovedOnClose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goiban")
+	if err != nil {
+		t.Errorf("failed to create a directory %v", err)
+		t.FailNow()
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "goiban.sock")
+
+	// leave a stale socket file behind like a crashed process
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Errorf("failed to listen on %v: %v", path, err)
+		t.FailNow()
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	listener, err := newListener("", "unix:"+path)
+	if err != nil {
+		t.Errorf("failed to replace the stale socket %v", err)
+		t.FailNow()
+	}
+	listener.Close()
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the socket file to be removed")
+	}
+}
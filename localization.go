@@ -0,0 +1,274 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Supported message languages, English is the default
+const (
+	LANGUAGE_EN = "en"
+	LANGUAGE_DE = "de"
+	LANGUAGE_FR = "fr"
+)
+
+var supportedLanguages = map[string]bool{
+	LANGUAGE_EN: true,
+	LANGUAGE_DE: true,
+	LANGUAGE_FR: true,
+}
+
+// messageCatalog contains the translations of the standard messages keyed
+// by a message ID. The English text is the one written by the handlers and
+// the goiban library, %v marks a variable part which is kept as is.
+var messageCatalog = map[string]map[string]string{
+	"emptyRequest": {
+		LANGUAGE_EN: "Empty request.",
+		LANGUAGE_DE: "Leere Anfrage.",
+		LANGUAGE_FR: "Requête vide.",
+	},
+	"inputTooLong": {
+		LANGUAGE_EN: "Input exceeds the maximum length of %v characters.",
+		LANGUAGE_DE: "Die Eingabe überschreitet die maximale Länge von %v Zeichen.",
+		LANGUAGE_FR: "L'entrée dépasse la longueur maximale de %v caractères.",
+	},
+	"ibanTooLong": {
+		LANGUAGE_EN: "IBAN exceeds maximum length of %v characters.",
+		LANGUAGE_DE: "Die IBAN überschreitet die maximale Länge von %v Zeichen.",
+		LANGUAGE_FR: "L'IBAN dépasse la longueur maximale de %v caractères.",
+	},
+	"notParseable": {
+		LANGUAGE_EN: "Cannot parse as IBAN: %v",
+		LANGUAGE_DE: "Kann nicht als IBAN gelesen werden: %v",
+		LANGUAGE_FR: "Impossible de lire comme IBAN : %v",
+	},
+	"invalidCharacters": {
+		LANGUAGE_EN: "Invalid characters found: %v",
+		LANGUAGE_DE: "Ungültige Zeichen gefunden: %v",
+		LANGUAGE_FR: "Caractères invalides trouvés : %v",
+	},
+	"countryNotSupported": {
+		LANGUAGE_EN: "Country not supported: %v",
+		LANGUAGE_DE: "Land wird nicht unterstützt: %v",
+		LANGUAGE_FR: "Pays non pris en charge : %v",
+	},
+	"countryMismatch": {
+		LANGUAGE_EN: "Expected an IBAN from %v, got %v.",
+		LANGUAGE_DE: "IBAN aus %v erwartet, erhalten: %v.",
+		LANGUAGE_FR: "IBAN de %v attendu, reçu : %v.",
+	},
+	"accountNumberLength": {
+		LANGUAGE_EN: "Expected an account number of %v characters, got %v.",
+		LANGUAGE_DE: "Kontonummer mit %v Zeichen erwartet, erhalten: %v.",
+		LANGUAGE_FR: "Numéro de compte de %v caractères attendu, reçu : %v.",
+	},
+	"accountNumberLengthUnchecked": {
+		LANGUAGE_EN: "The account number length can't be checked for %v.",
+		LANGUAGE_DE: "Die Länge der Kontonummer kann für %v nicht geprüft werden.",
+		LANGUAGE_FR: "La longueur du numéro de compte ne peut pas être vérifiée pour %v.",
+	},
+	"badLength": {
+		LANGUAGE_EN: "Expected %v characters for %v, got %v",
+		LANGUAGE_DE: "%v Zeichen für %v erwartet, erhalten: %v",
+		LANGUAGE_FR: "%v caractères attendus pour %v, reçu : %v",
+	},
+	"structureMismatch": {
+		LANGUAGE_EN: "Expected the BBAN format %v for %v",
+		LANGUAGE_DE: "BBAN-Format %v für %v erwartet",
+		LANGUAGE_FR: "Format BBAN %v attendu pour %v",
+	},
+	"badChecksum": {
+		LANGUAGE_EN: "Invalid IBAN checksum.",
+		LANGUAGE_DE: "Ungültige IBAN-Prüfsumme.",
+		LANGUAGE_FR: "Clé de contrôle IBAN invalide.",
+	},
+	"bankCodeValid": {
+		LANGUAGE_EN: "Bank code valid: %v",
+		LANGUAGE_DE: "Bankleitzahl gültig: %v",
+		LANGUAGE_FR: "Code banque valide : %v",
+	},
+	"bankCodeNotFound": {
+		LANGUAGE_EN: "Bank code invalid: %v",
+		LANGUAGE_DE: "Bankleitzahl ungültig: %v",
+		LANGUAGE_FR: "Code banque invalide : %v",
+	},
+	"bankCodeUnknown": {
+		LANGUAGE_EN: "Cannot validate bank code length. No information available.",
+		LANGUAGE_DE: "Die Bankleitzahl kann nicht geprüft werden. Keine Informationen verfügbar.",
+		LANGUAGE_FR: "Impossible de vérifier le code banque. Aucune information disponible.",
+	},
+	"bicNotFoundForBankCode": {
+		LANGUAGE_EN: "No BIC found for bank code: %v",
+		LANGUAGE_DE: "Kein BIC für die Bankleitzahl gefunden: %v",
+		LANGUAGE_FR: "Aucun BIC trouvé pour le code banque : %v",
+	},
+	"bicMissing": {
+		LANGUAGE_EN: "No BIC found for the bank code.",
+		LANGUAGE_DE: "Kein BIC für die Bankleitzahl gefunden.",
+		LANGUAGE_FR: "Aucun BIC trouvé pour le code banque.",
+	},
+	"bankDataUnavailable": {
+		LANGUAGE_EN: "Bank data is currently unavailable.",
+		LANGUAGE_DE: "Die Bankdaten sind derzeit nicht verfügbar.",
+		LANGUAGE_FR: "Les données bancaires sont actuellement indisponibles.",
+	},
+	"bankDataTimeout": {
+		LANGUAGE_EN: "Bank data lookup timed out.",
+		LANGUAGE_DE: "Die Abfrage der Bankdaten hat zu lange gedauert.",
+		LANGUAGE_FR: "La recherche des données bancaires a expiré.",
+	},
+	"bankDataSchema": {
+		LANGUAGE_EN: "Bank data table is missing.",
+		LANGUAGE_DE: "Die Tabelle der Bankdaten fehlt.",
+		LANGUAGE_FR: "La table des données bancaires est manquante.",
+	},
+	"bankDataBusy": {
+		LANGUAGE_EN: "Too many concurrent bank data lookups.",
+		LANGUAGE_DE: "Zu viele gleichzeitige Abfragen der Bankdaten.",
+		LANGUAGE_FR: "Trop de recherches simultanées de données bancaires.",
+	},
+	"invalidExpectedCountry": {
+		LANGUAGE_EN: "Expected a two letter country code as expectedCountry.",
+		LANGUAGE_DE: "Für expectedCountry wird ein zweistelliger Ländercode erwartet.",
+		LANGUAGE_FR: "Un code pays à deux lettres est attendu pour expectedCountry.",
+	},
+	"unknownCountry": {
+		LANGUAGE_EN: "Unknown country code.",
+		LANGUAGE_DE: "Unbekannter Ländercode.",
+		LANGUAGE_FR: "Code pays inconnu.",
+	},
+	"invalidBic": {
+		LANGUAGE_EN: "Expected a BIC with 8 or 11 characters.",
+		LANGUAGE_DE: "Es wird ein BIC mit 8 oder 11 Zeichen erwartet.",
+		LANGUAGE_FR: "Un BIC de 8 ou 11 caractères est attendu.",
+	},
+	"bicNotFound": {
+		LANGUAGE_EN: "Unknown BIC.",
+		LANGUAGE_DE: "Unbekannter BIC.",
+		LANGUAGE_FR: "BIC inconnu.",
+	},
+	"unauthorized": {
+		LANGUAGE_EN: "Missing or invalid API key.",
+		LANGUAGE_DE: "Fehlender oder ungültiger API-Schlüssel.",
+		LANGUAGE_FR: "Clé d'API manquante ou invalide.",
+	},
+	"notFound": {
+		LANGUAGE_EN: "Not found",
+		LANGUAGE_DE: "Nicht gefunden",
+		LANGUAGE_FR: "Introuvable",
+	},
+	"maintenance": {
+		LANGUAGE_EN: "The service is down for maintenance, please try again later.",
+		LANGUAGE_DE: "Der Dienst wird gerade gewartet, bitte versuchen Sie es später erneut.",
+		LANGUAGE_FR: "Le service est en maintenance, veuillez réessayer plus tard.",
+	},
+}
+
+// translation replaces the JSON string of an English message
+type translation struct {
+	pattern *regexp.Regexp
+	formats map[string]string
+}
+
+var translations = compileTranslations()
+
+// Compiles the catalog into patterns matching the English messages as
+// complete JSON strings. The variable parts are captured and inserted
+// into the translated message.
+func compileTranslations() []translation {
+	ids := make([]string, 0, len(messageCatalog))
+	for id := range messageCatalog {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	result := make([]translation, 0, len(ids))
+	for _, id := range ids {
+		messages := messageCatalog[id]
+
+		pattern := regexp.QuoteMeta(jsonString(messages[LANGUAGE_EN]))
+		pattern = strings.Replace(pattern, "%v", `((?:[^"\\]|\\.)*?)`, -1)
+
+		formats := map[string]string{}
+		for language, message := range messages {
+			formats[language] = jsonString(message)
+		}
+
+		result = append(result, translation{regexp.MustCompile(pattern), formats})
+	}
+
+	return result
+}
+
+// Returns message as a quoted JSON string
+func jsonString(message string) string {
+	data, _ := json.Marshal(message)
+	return string(data)
+}
+
+// Returns the supported language the client prefers according to its
+// Accept-Language header, or English.
+func messageLanguage(r *http.Request) string {
+	header := r.Header.Get("Accept-Language")
+	if header == "" {
+		return LANGUAGE_EN
+	}
+
+	best := LANGUAGE_EN
+	bestQuality := 0.0
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		language := strings.SplitN(tag, "-", 2)[0]
+
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				q, err := strconv.ParseFloat(param[2:], 64)
+				if err == nil {
+					quality = q
+				}
+			}
+		}
+
+		if supportedLanguages[language] && quality > bestQuality {
+			best = language
+			bestQuality = quality
+		}
+	}
+
+	return best
+}
+
+// Translates the standard messages of a JSON response. Error codes and
+// all other values are left untouched.
+func localize(data []byte, language string) []byte {
+	if language == LANGUAGE_EN {
+		return data
+	}
+
+	for _, t := range translations {
+		format, ok := t.formats[language]
+		if !ok {
+			continue
+		}
+
+		data = t.pattern.ReplaceAllFunc(data, func(match []byte) []byte {
+			groups := t.pattern.FindSubmatch(match)
+			args := make([]interface{}, len(groups)-1)
+			for i, group := range groups[1:] {
+				args[i] = string(group)
+			}
+
+			return []byte(fmt.Sprintf(format, args...))
+		})
+	}
+
+	return data
+}
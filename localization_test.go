@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMessageLanguage(t *testing.T) {
+	cases := map[string]string{
+		"":                          LANGUAGE_EN,
+		"de":                        LANGUAGE_DE,
+		"de-DE,de;q=0.9":            LANGUAGE_DE,
+		"fr-CH, fr;q=0.9, en;q=0.8": LANGUAGE_FR,
+		"es, de;q=0.5":              LANGUAGE_DE,
+		"de;q=0.5, en":              LANGUAGE_EN,
+		"es":                        LANGUAGE_EN,
+		"fr;q=0":                    LANGUAGE_EN,
+	}
+
+	for header, expected := range cases {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept-Language", header)
+
+		if language := messageLanguage(r); language != expected {
+			t.Errorf("expected %v for %q, got %v", expected, header, language)
+		}
+	}
+}
+
+func TestLocalizeKeepsVariableParts(t *testing.T) {
+	data := []byte(`{"messages":["Expected an IBAN from DE, got BE."]}`)
+
+	localized := string(localize(data, LANGUAGE_DE))
+	if localized != `{"messages":["IBAN aus DE erwartet, erhalten: BE."]}` {
+		t.Errorf("unexpected translation %v", localized)
+	}
+
+	if string(localize(data, LANGUAGE_EN)) != string(data) {
+		t.Errorf("expected English messages to be unchanged")
+	}
+}
+
+func TestLocalizedErrorKeepsErrorCode(t *testing.T) {
+	req, _ := http.NewRequest("GET", server.URL+"/validate/XX", nil)
+	req.Header.Set("Accept-Language", "fr")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Errorf("failed to validate %v", err)
+		t.FailNow()
+	}
+	defer resp.Body.Close()
+
+	var result ValidationResponse
+	json.NewDecoder(resp.Body).Decode(&result)
+
+	if result.ErrorCode != ERROR_NOT_PARSEABLE {
+		t.Errorf("expected error code %v, got %v", ERROR_NOT_PARSEABLE, result.ErrorCode)
+	}
+
+	if len(result.Messages) != 1 || !strings.HasPrefix(result.Messages[0], "Impossible de lire comme IBAN") {
+		t.Errorf("expected a French message, got %v", result.Messages)
+	}
+
+	if resp.Header.Get("Content-Language") != LANGUAGE_FR {
+		t.Errorf("expected Content-Language fr, got %v", resp.Header.Get("Content-Language"))
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// lruCache keeps at most maxEntries results in process. When it is full
+// the least recently used entry is evicted, so a flood of distinct inputs
+// can't grow the memory without bounds. Entries still expire after their
+// TTL, expired entries are removed every cleanup interval.
+type lruCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]*list.Element
+	// order holds the entries from the most to the least recently used
+	order *list.List
+}
+
+type lruEntry struct {
+	key     string
+	value   string
+	expires time.Time
+}
+
+func newLRUCache(ttl time.Duration, cleanup time.Duration, maxEntries int) *lruCache {
+	lc := &lruCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		entries:    map[string]*list.Element{},
+		order:      list.New(),
+	}
+
+	if cleanup > 0 {
+		go func() {
+			for range time.Tick(cleanup) {
+				lc.deleteExpired()
+			}
+		}()
+	}
+
+	return lc
+}
+
+func (lc *lruCache) Get(key string) (string, bool) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	element, ok := lc.entries[key]
+	if !ok {
+		return "", false
+	}
+
+	entry := element.Value.(*lruEntry)
+	if entry.expired(time.Now()) {
+		lc.remove(element)
+		return "", false
+	}
+
+	lc.order.MoveToFront(element)
+	return entry.value, true
+}
+
+// Set stores the value for ttl, 0 uses the default TTL of the cache and a
+// negative ttl keeps the value until it is evicted
+func (lc *lruCache) Set(key string, value string, ttl time.Duration) {
+	if ttl == 0 {
+		ttl = lc.ttl
+	}
+
+	var expires time.Time
+	if ttl > 0 {
+		expires = time.Now().Add(ttl)
+	}
+
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	if element, ok := lc.entries[key]; ok {
+		entry := element.Value.(*lruEntry)
+		entry.value, entry.expires = value, expires
+		lc.order.MoveToFront(element)
+		return
+	}
+
+	lc.entries[key] = lc.order.PushFront(&lruEntry{key, value, expires})
+
+	for lc.order.Len() > lc.maxEntries {
+		lc.remove(lc.order.Back())
+		registerCacheEviction()
+	}
+
+	registerCacheSize(lc.order.Len())
+}
+
+func (lc *lruCache) Delete(key string) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	if element, ok := lc.entries[key]; ok {
+		lc.remove(element)
+	}
+}
+
+// Len returns the number of entries, including expired entries which
+// haven't been removed yet
+func (lc *lruCache) Len() int {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	return lc.order.Len()
+}
+
+func (lc *lruCache) deleteExpired() {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	now := time.Now()
+	for element := lc.order.Back(); element != nil; {
+		previous := element.Prev()
+		if element.Value.(*lruEntry).expired(now) {
+			lc.remove(element)
+		}
+		element = previous
+	}
+}
+
+// remove deletes the entry, the caller holds the lock
+func (lc *lruCache) remove(element *list.Element) {
+	lc.order.Remove(element)
+	delete(lc.entries, element.Value.(*lruEntry).key)
+	registerCacheSize(lc.order.Len())
+}
+
+func (e *lruEntry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && now.After(e.expires)
+}
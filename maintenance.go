@@ -0,0 +1,37 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// MAINTENANCE_MESSAGE is the message of the responses in maintenance mode
+const MAINTENANCE_MESSAGE = "The service is down for maintenance, please try again later."
+
+// Answers the requests with HTTP 503 while the service is in maintenance
+// mode. Maintenance mode is switched on and off by reloading the config, so
+// the routes don't have to be registered again.
+func unlessMaintenance(h httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		current := settings()
+		if !current.Maintenance {
+			h(w, r, ps)
+			return
+		}
+
+		// Allow CORS
+		allowOrigin(w, r)
+
+		w.Header().Set("Retry-After", retryAfter(current.MaintenanceRetryAfter.Seconds()))
+		writeError(w, r, http.StatusServiceUnavailable, ERROR_MAINTENANCE, MAINTENANCE_MESSAGE)
+	}
+}
+
+// Returns the Retry-After header value of the duration in seconds, rounded
+// up to whole seconds
+func retryAfter(seconds float64) string {
+	return strconv.Itoa(int(math.Ceil(seconds)))
+}
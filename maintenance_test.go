@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+func TestMaintenanceModeReturns503(t *testing.T) {
+	defer func() { reloaded = nil }()
+
+	called := false
+	handler := unlessMaintenance(func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		called = true
+	})
+
+	handler(httptest.NewRecorder(), httptest.NewRequest("GET", "/validate/DE89370400440532013000", nil), nil)
+	if !called {
+		t.Errorf("expected the request to be handled outside of maintenance mode")
+	}
+
+	maintenance := reloadableSettings(cfg)
+	maintenance.Maintenance = true
+	maintenance.MaintenanceRetryAfter = Duration{90 * time.Second}
+	reloaded = maintenance
+
+	called = false
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/validate/DE89370400440532013000", nil), nil)
+
+	if called {
+		t.Errorf("expected the request not to be handled in maintenance mode")
+	}
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status %v, got %v", http.StatusServiceUnavailable, w.Code)
+	}
+
+	if w.Header().Get("Retry-After") != "90" {
+		t.Errorf("expected Retry-After 90, got %v", w.Header().Get("Retry-After"))
+	}
+
+	var result ValidationResponse
+	err := json.NewDecoder(w.Body).Decode(&result)
+	if err != nil {
+		t.Errorf("failed to decode result %v", err)
+		t.FailNow()
+	}
+
+	if result.ErrorCode != ERROR_MAINTENANCE {
+		t.Errorf("expected error code %v, got %v", ERROR_MAINTENANCE, result.ErrorCode)
+	}
+}
+
+func TestMaintenanceModeKeepsHealth(t *testing.T) {
+	defer func() { reloaded = nil }()
+
+	maintenance := reloadableSettings(cfg)
+	maintenance.Maintenance = true
+	reloaded = maintenance
+
+	resp, err := http.Get(server.URL + "/health")
+	if err != nil {
+		t.Errorf("failed to get health %v", err)
+		t.FailNow()
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected /health to report %v, got %v", http.StatusOK, resp.StatusCode)
+	}
+
+	resp, err = http.Get(server.URL + "/ready")
+	if err != nil {
+		t.Errorf("failed to get ready %v", err)
+		t.FailNow()
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected /ready to report %v, got %v", http.StatusServiceUnavailable, resp.StatusCode)
+	}
+}
@@ -0,0 +1,23 @@
+package metrics
+
+import (
+	goiban "github.com/fourcube/goiban"
+)
+
+// Metrics is a backend the validation events are logged to. The service
+// uses one backend, selected with the metricsBackend setting.
+type Metrics interface {
+	// WriteLogRequest logs the calculation of iban
+	WriteLogRequest(collectionName string, iban *goiban.Iban)
+	// LogRequestFromValidationResult logs the validation with the JSON
+	// encoded ValidationResult
+	LogRequestFromValidationResult(collectionName string, validationResult string)
+	// Close sends the buffered events and releases the backend
+	Close()
+}
+
+var (
+	_ Metrics = &InmemMetricsRegister{}
+	_ Metrics = &KeenMetrics{}
+	_ Metrics = &StatsdMetrics{}
+)
@@ -27,33 +27,150 @@ package metrics
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
+	"strings"
+	"sync"
+	"time"
 
 	goiban "github.com/fourcube/goiban"
 	"github.com/franela/goreq"
 )
 
+// Defaults of the event batching
+const (
+	DEFAULT_KEEN_FLUSH_INTERVAL = 10 * time.Second
+	DEFAULT_KEEN_BATCH_SIZE     = 100
+)
+
+// KEEN_CHECK_TIMEOUT bounds the credentials check at startup
+const KEEN_CHECK_TIMEOUT = 5 * time.Second
+
+var keenAPI = "http://api.keen.io/3.0/projects/"
+
 // KeenMetrics is deprecated
+//
+// Events are buffered and posted to keen.io in batches every FlushInterval
+// or as soon as BatchSize events are pending. Close flushes the remaining
+// events. Collection replaces the collection names given by the callers
+// when it is set.
 type KeenMetrics struct {
-	ProjectID   string
-	WriteAPIKey string
+	ProjectID     string
+	WriteAPIKey   string
+	FlushInterval time.Duration
+	BatchSize     int
+	Collection    string
+
+	mu      sync.Mutex
+	pending map[string][]Event
+	size    int
+
+	start sync.Once
+	stop  sync.Once
+	done  chan struct{}
 }
 
 func (keen *KeenMetrics) getEndpoint() string {
-	return "http://api.keen.io/3.0/projects/" + keen.ProjectID + "/events/"
+	return keenAPI + keen.ProjectID + "/events/"
 }
 
 //WriteLogRequest logs to keen.io
 //
 // http://api.keen.io/3.0/projects/<project_id>/events/<event_collection>
 func (keen *KeenMetrics) WriteLogRequest(collectionName string, iban *goiban.Iban) {
-	var url = keen.getEndpoint() + collectionName
+	keen.enqueue(keen.collection(collectionName), IbanToEvent(iban))
+}
+
+//LogRequestFromValidationResult unmarshalls the ValidationResult and logs to keen.io
+//
+//http://api.keen.io/3.0/projects/<project_id>/events/<event_collection>
+func (keen *KeenMetrics) LogRequestFromValidationResult(collectionName string, validationResult string) {
+	var result goiban.ValidationResult
+	json.Unmarshal([]byte(validationResult), &result)
+
+	keen.enqueue(keen.collection(collectionName), ValidationResultToEvent(&result))
+}
+
+// Returns the configured collection name, or collectionName
+func (keen *KeenMetrics) collection(collectionName string) string {
+	if keen.Collection != "" {
+		return keen.Collection
+	}
+
+	return collectionName
+}
+
+// Adds the event to the buffer and flushes it when it is full
+func (keen *KeenMetrics) enqueue(collectionName string, event Event) {
+	keen.start.Do(keen.run)
+
+	keen.mu.Lock()
+	if keen.pending == nil {
+		keen.pending = map[string][]Event{}
+	}
+	keen.pending[collectionName] = append(keen.pending[collectionName], event)
+	keen.size++
+	full := keen.size >= keen.batchSize()
+	keen.mu.Unlock()
+
+	if full {
+		keen.Flush()
+	}
+}
+
+// Starts flushing the buffer periodically
+func (keen *KeenMetrics) run() {
+	keen.done = make(chan struct{})
+
+	interval := keen.FlushInterval
+	if interval <= 0 {
+		interval = DEFAULT_KEEN_FLUSH_INTERVAL
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				keen.Flush()
+			case <-keen.done:
+				return
+			}
+		}
+	}()
+}
+
+func (keen *KeenMetrics) batchSize() int {
+	if keen.BatchSize <= 0 {
+		return DEFAULT_KEEN_BATCH_SIZE
+	}
+
+	return keen.BatchSize
+}
+
+// Flush posts all pending events in a single request
+//
+// http://api.keen.io/3.0/projects/<project_id>/events
+func (keen *KeenMetrics) Flush() {
+	keen.mu.Lock()
+	events := keen.pending
+	keen.pending = nil
+	keen.size = 0
+	keen.mu.Unlock()
+
+	if len(events) == 0 {
+		return
+	}
+
+	var url = strings.TrimSuffix(keen.getEndpoint(), "/")
 
 	req := goreq.Request{
 		Method:      "POST",
 		Uri:         url,
 		ContentType: "application/json",
-		Body:        IbanToEvent(iban),
+		Body:        events,
 	}
 
 	req.AddHeader("Authorization", keen.WriteAPIKey)
@@ -70,48 +187,51 @@ func (keen *KeenMetrics) WriteLogRequest(collectionName string, iban *goiban.Iba
 		defer res.Body.Close()
 	}
 
-	if collectionName == "Test" {
+	if _, ok := events["Test"]; ok {
 		log.Printf(url)
 		text, _ := res.Body.ToString()
 		log.Printf("Response (%v): %v", res.StatusCode, text)
 	}
-
 }
 
-//LogRequestFromValidationResult unmarshalls the ValidationResult and logs to keen.io
-//
-//http://api.keen.io/3.0/projects/<project_id>/events/<event_collection>
-func (keen *KeenMetrics) LogRequestFromValidationResult(collectionName string, validationResult string) {
-	var url = keen.getEndpoint() + collectionName
-
-	var result goiban.ValidationResult
-	json.Unmarshal([]byte(validationResult), &result)
-
+// Check verifies the project ID and write key by posting an empty batch,
+// which is authenticated like a real one but records no events
+func (keen *KeenMetrics) Check() error {
 	req := goreq.Request{
 		Method:      "POST",
-		Uri:         url,
+		Uri:         strings.TrimSuffix(keen.getEndpoint(), "/"),
 		ContentType: "application/json",
-		Body:        ValidationResultToEvent(&result),
+		Body:        map[string][]Event{},
+		Timeout:     KEEN_CHECK_TIMEOUT,
 	}
 
 	req.AddHeader("Authorization", keen.WriteAPIKey)
 
 	res, err := req.Do()
-
 	if err != nil {
-		log.Printf("Error while posting stats: %v", err)
-		return
+		return err
 	}
 
-	// Close the response body
 	if res.Body != nil {
 		defer res.Body.Close()
 	}
 
-	if collectionName == "Test" {
-		log.Printf(url)
+	if res.StatusCode < 200 || res.StatusCode > 299 {
 		text, _ := res.Body.ToString()
-		log.Printf("Response (%v): %v", res.StatusCode, text)
+		return fmt.Errorf("keen.io responded with %v: %v", res.StatusCode, text)
 	}
 
+	return nil
+}
+
+// Close stops the periodic flushing and flushes the pending events
+func (keen *KeenMetrics) Close() {
+	keen.stop.Do(func() {
+		keen.start.Do(func() {})
+		if keen.done != nil {
+			close(keen.done)
+		}
+	})
+
+	keen.Flush()
 }
@@ -26,13 +26,24 @@ THE SOFTWARE.
 package metrics
 
 import (
+	"time"
+
 	goiban "github.com/fourcube/goiban"
 )
 
+// Defaults of the event batching
+const (
+	DEFAULT_KEEN_FLUSH_INTERVAL = 10 * time.Second
+	DEFAULT_KEEN_BATCH_SIZE     = 100
+)
+
 // KeenMetrics is deprecated
 type KeenMetrics struct {
-	ProjectID   string
-	WriteAPIKey string
+	ProjectID     string
+	WriteAPIKey   string
+	FlushInterval time.Duration
+	BatchSize     int
+	Collection    string
 }
 
 func (keen *KeenMetrics) getEndpoint() string {
@@ -52,3 +63,18 @@ func (keen *KeenMetrics) WriteLogRequest(collectionName string, iban *goiban.Iba
 func (keen *KeenMetrics) LogRequestFromValidationResult(collectionName string, validationResult string) {
 
 }
+
+// Flush posts all pending events in a single request
+func (keen *KeenMetrics) Flush() {
+
+}
+
+// Check verifies the project ID and write key
+func (keen *KeenMetrics) Check() error {
+	return nil
+}
+
+// Close stops the periodic flushing and flushes the pending events
+func (keen *KeenMetrics) Close() {
+
+}
@@ -0,0 +1,102 @@
+// +build !no_metrics
+
+package metrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	goiban "github.com/fourcube/goiban"
+)
+
+func TestKeenMetricsSendsBatches(t *testing.T) {
+	batches := make(chan map[string][]Event, 10)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/project/events" {
+			t.Errorf("unexpected path %v", r.URL.Path)
+		}
+
+		var batch map[string][]Event
+		json.NewDecoder(r.Body).Decode(&batch)
+		batches <- batch
+	}))
+	defer api.Close()
+
+	defer func(url string) { keenAPI = url }(keenAPI)
+	keenAPI = api.URL + "/"
+
+	keen := &KeenMetrics{ProjectID: "project", FlushInterval: time.Hour, BatchSize: 2}
+	result := goiban.NewValidationResult(true, "", "DE89370400440532013000")
+	data, _ := json.Marshal(result)
+
+	keen.LogRequestFromValidationResult("Live", string(data))
+	keen.LogRequestFromValidationResult("Live", string(data))
+
+	// the full buffer is sent right away
+	select {
+	case batch := <-batches:
+		if len(batch["Live"]) != 2 || batch["Live"][0].Country != "DE" {
+			t.Errorf("unexpected batch %v", batch)
+		}
+	case <-time.After(time.Second):
+		t.Errorf("expected a batch once the buffer is full")
+	}
+
+	// the remainder is sent on close
+	keen.LogRequestFromValidationResult("Test", string(data))
+	keen.Close()
+
+	select {
+	case batch := <-batches:
+		if len(batch["Test"]) != 1 {
+			t.Errorf("unexpected batch %v", batch)
+		}
+	default:
+		t.Errorf("expected the pending events to be sent on close")
+	}
+}
+
+func TestKeenMetricsCollection(t *testing.T) {
+	keen := &KeenMetrics{Collection: "goiban"}
+	if name := keen.collection("Live"); name != "goiban" {
+		t.Errorf("expected the configured collection, got %v", name)
+	}
+
+	keen.Collection = ""
+	if name := keen.collection("Live"); name != "Live" {
+		t.Errorf("expected the given collection, got %v", name)
+	}
+}
+
+func TestKeenMetricsCheck(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "valid" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"message": "Incorrect API Key."}`))
+			return
+		}
+
+		var batch map[string][]Event
+		json.NewDecoder(r.Body).Decode(&batch)
+		if len(batch) != 0 {
+			t.Errorf("expected the check to send no events, got %v", batch)
+		}
+	}))
+	defer api.Close()
+
+	defer func(url string) { keenAPI = url }(keenAPI)
+	keenAPI = api.URL + "/"
+
+	keen := &KeenMetrics{ProjectID: "project", WriteAPIKey: "valid"}
+	if err := keen.Check(); err != nil {
+		t.Errorf("expected valid credentials to pass, got %v", err)
+	}
+
+	keen.WriteAPIKey = "typo"
+	if err := keen.Check(); err == nil {
+		t.Errorf("expected a wrong write key to fail the check")
+	}
+}
@@ -0,0 +1,31 @@
+package metrics
+
+import "strings"
+
+// MaskIbans controls whether SafeIban masks the IBANs it is given
+var MaskIbans = true
+
+// MaskIban keeps the country code, check digits and the last two characters
+// of iban and replaces the rest of the BBAN with asterisks, e.g.
+// DE89370400440532013000 becomes DE89****************00.
+func MaskIban(iban string) string {
+	if len(iban) <= 4 {
+		return iban
+	}
+
+	if len(iban) <= 6 {
+		return iban[:4] + strings.Repeat("*", len(iban)-4)
+	}
+
+	return iban[:4] + strings.Repeat("*", len(iban)-6) + iban[len(iban)-2:]
+}
+
+// SafeIban returns iban in a form that can be written to logs and
+// metrics. The IBAN is masked unless masking has been disabled.
+func SafeIban(iban string) string {
+	if MaskIbans {
+		return MaskIban(iban)
+	}
+
+	return iban
+}
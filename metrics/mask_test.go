@@ -0,0 +1,27 @@
+package metrics
+
+import "testing"
+
+func TestMaskIban(t *testing.T) {
+	cases := map[string]string{
+		"DE89370400440532013000": "DE89****************00",
+		"DE8937":                 "DE89**",
+		"DE89":                   "DE89",
+		"":                       "",
+	}
+
+	for iban, expected := range cases {
+		if masked := MaskIban(iban); masked != expected {
+			t.Errorf("expected %v to be masked as %v, got %v", iban, expected, masked)
+		}
+	}
+}
+
+func TestSafeIbanWithoutMasking(t *testing.T) {
+	defer func() { MaskIbans = true }()
+	MaskIbans = false
+
+	if iban := SafeIban("DE89370400440532013000"); iban != "DE89370400440532013000" {
+		t.Errorf("expected unmasked iban, got %v", iban)
+	}
+}
@@ -5,6 +5,9 @@ package metrics
 import (
 	"encoding/json"
 	"net/http"
+	"regexp"
+	"strings"
+	"sync"
 	"time"
 
 	gm "github.com/armon/go-metrics"
@@ -15,26 +18,200 @@ type Event struct {
 	Country string
 }
 
+// Outcomes of a validation counted per country by RegisterOutcome
+const (
+	OUTCOME_VALID       = "valid"
+	OUTCOME_INVALID     = "invalid"
+	OUTCOME_UNPARSEABLE = "unparseable"
+
+	// UNKNOWN_COUNTRY is the country of input which doesn't start with a
+	// country code
+	UNKNOWN_COUNTRY = "unknown"
+)
+
+// Stats summarizes the validations held by the in-memory register
+type Stats struct {
+	Total     int            `json:"total"`
+	Valid     int            `json:"valid"`
+	Invalid   int            `json:"invalid"`
+	Countries map[string]int `json:"countries"`
+
+	// Outcomes counts the validations per country and outcome
+	Outcomes map[string]map[string]int `json:"outcomes"`
+}
+
 //
 type MetricsRegister interface {
 	Register(Event)
+	RegisterLatency(name string, d time.Duration)
+	RegisterCacheHit()
+	RegisterCacheMiss()
+	RegisterCacheEviction()
+	RegisterCacheSize(size int)
+	RegisterBreakerState(state int)
+	RegisterRequest(method string, route string, status int, d time.Duration)
+	RegisterResult(valid bool)
+	RegisterOutcome(countryCode string, outcome string)
+	Stats() Stats
 	Data() []*gm.IntervalMetrics
+	Reset()
 }
 
 type InmemMetricsRegister struct {
-	*gm.InmemSink
+	mu   sync.RWMutex
+	sink *gm.InmemSink
 }
 
 func NewInmemMetricsRegister() *InmemMetricsRegister {
 	return &InmemMetricsRegister{
-		gm.NewInmemSink(5*time.Minute, 24*7*time.Hour),
+		sink: newInmemSink(),
 	}
 }
 
+func newInmemSink() *gm.InmemSink {
+	return gm.NewInmemSink(5*time.Minute, 24*7*time.Hour)
+}
+
+// inmem returns the current sink, it is replaced by Reset
+func (imr *InmemMetricsRegister) inmem() *gm.InmemSink {
+	imr.mu.RLock()
+	defer imr.mu.RUnlock()
+
+	return imr.sink
+}
+
+// Reset drops all registered metrics
+func (imr *InmemMetricsRegister) Reset() {
+	imr.mu.Lock()
+	defer imr.mu.Unlock()
+
+	imr.sink = newInmemSink()
+}
+
+// Data returns the retained intervals
+func (imr *InmemMetricsRegister) Data() []*gm.IntervalMetrics {
+	return imr.inmem().Data()
+}
+
 func (imr *InmemMetricsRegister) Register(e Event) {
-	imr.IncrCounter([]string{e.Country}, 1.0)
+	imr.inmem().IncrCounter([]string{e.Country}, 1.0)
+}
+
+// RegisterLatency records the duration of an operation in milliseconds.
+// The samples are aggregated (count, min, max, mean) per interval.
+func (imr *InmemMetricsRegister) RegisterLatency(name string, d time.Duration) {
+	imr.inmem().AddSample([]string{"latency", name}, float32(d.Seconds()*1000))
+}
+
+// RegisterCacheHit counts a validation result served from the cache
+func (imr *InmemMetricsRegister) RegisterCacheHit() {
+	imr.inmem().IncrCounter([]string{"cache", "hit"}, 1.0)
+}
+
+// RegisterCacheMiss counts a validation result that was not cached
+func (imr *InmemMetricsRegister) RegisterCacheMiss() {
+	imr.inmem().IncrCounter([]string{"cache", "miss"}, 1.0)
+}
+
+// RegisterCacheEviction counts a result evicted from the full cache
+func (imr *InmemMetricsRegister) RegisterCacheEviction() {
+	imr.inmem().IncrCounter([]string{"cache", "eviction"}, 1.0)
+}
+
+// RegisterCacheSize records the number of cached results as gauge
+func (imr *InmemMetricsRegister) RegisterCacheSize(size int) {
+	imr.inmem().SetGauge([]string{"cache", "size"}, float32(size))
+}
+
+// RegisterBreakerState records the state of the database circuit breaker
+// as gauge
+func (imr *InmemMetricsRegister) RegisterBreakerState(state int) {
+	imr.inmem().SetGauge([]string{"db", "breaker"}, float32(state))
+}
+
+// RegisterRequest counts an HTTP request as http.requests.<method>.<route>
+// and records its latency in milliseconds as http.latency.<method>.<route>.
+// Server errors (5xx) are also counted as http.errors.<method>.<route>.
+func (imr *InmemMetricsRegister) RegisterRequest(method string, route string, status int, d time.Duration) {
+	sink := imr.inmem()
+	sink.IncrCounter([]string{"http", "requests", method, route}, 1.0)
+	sink.AddSample([]string{"http", "latency", method, route}, float32(d.Seconds()*1000))
+
+	if status >= 500 {
+		sink.IncrCounter([]string{"http", "errors", method, route}, 1.0)
+	}
+}
+
+// RegisterResult counts a validation by its result
+func (imr *InmemMetricsRegister) RegisterResult(valid bool) {
+	if valid {
+		imr.inmem().IncrCounter([]string{"validation", "valid"}, 1.0)
+	} else {
+		imr.inmem().IncrCounter([]string{"validation", "invalid"}, 1.0)
+	}
+}
+
+// RegisterOutcome counts a validation by country and outcome, e.g. as
+// outcome.DE.valid
+func (imr *InmemMetricsRegister) RegisterOutcome(countryCode string, outcome string) {
+	imr.inmem().IncrCounter([]string{"outcome", countryCode, outcome}, 1.0)
+}
+
+// WriteLogRequest counts the calculation per country
+func (imr *InmemMetricsRegister) WriteLogRequest(collectionName string, iban *goiban.Iban) {
+	imr.Register(IbanToEvent(iban))
+}
+
+// LogRequestFromValidationResult counts the validation per country
+func (imr *InmemMetricsRegister) LogRequestFromValidationResult(collectionName string, validationResult string) {
+	var result goiban.ValidationResult
+	json.Unmarshal([]byte(validationResult), &result)
+
+	imr.Register(ValidationResultToEvent(&result))
+}
+
+// Close does nothing, the in-memory register holds no resources
+func (imr *InmemMetricsRegister) Close() {
+}
+
+// countryKey matches the counters registered per country
+var countryKey = regexp.MustCompile(`^[A-Z]{2}$`)
+
+// Stats sums up the counters of all retained intervals
+func (imr *InmemMetricsRegister) Stats() Stats {
+	stats := Stats{Countries: map[string]int{}, Outcomes: map[string]map[string]int{}}
+
+	for _, interval := range imr.Data() {
+		interval.RLock()
+		for key, counter := range interval.Counters {
+			switch {
+			case key == "validation.valid":
+				stats.Valid += counter.Count
+			case key == "validation.invalid":
+				stats.Invalid += counter.Count
+			case countryKey.MatchString(key):
+				stats.Countries[key] += counter.Count
+			case strings.HasPrefix(key, "outcome."):
+				parts := strings.Split(key, ".")
+				if len(parts) != 3 {
+					continue
+				}
+
+				if stats.Outcomes[parts[1]] == nil {
+					stats.Outcomes[parts[1]] = map[string]int{}
+				}
+				stats.Outcomes[parts[1]][parts[2]] += counter.Count
+			}
+		}
+		interval.RUnlock()
+	}
+
+	stats.Total = stats.Valid + stats.Invalid
+	return stats
 }
 
+// IbanToEvent creates the metrics event for iban. Events only carry the
+// country code, the IBAN itself never leaves the service.
 func IbanToEvent(iban *goiban.Iban) Event {
 	return Event{
 		Country: iban.GetCountryCode(),
@@ -49,8 +226,6 @@ func ValidationResultToEvent(validationResult *goiban.ValidationResult) Event {
 
 func (imr *InmemMetricsRegister) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	encoder := json.NewEncoder(w)
-	// Allow CORS
-	w.Header().Add("Access-Control-Allow-Origin", "*")
 	w.Header().Add("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	encoder.Encode(imr.Data())
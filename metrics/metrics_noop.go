@@ -4,6 +4,7 @@ package metrics
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/fourcube/goiban"
 )
@@ -12,9 +13,42 @@ type Event struct {
 	Country string
 }
 
+// Outcomes of a validation counted per country by RegisterOutcome
+const (
+	OUTCOME_VALID       = "valid"
+	OUTCOME_INVALID     = "invalid"
+	OUTCOME_UNPARSEABLE = "unparseable"
+
+	// UNKNOWN_COUNTRY is the country of input which doesn't start with a
+	// country code
+	UNKNOWN_COUNTRY = "unknown"
+)
+
+// Stats summarizes the validations held by the in-memory register
+type Stats struct {
+	Total     int            `json:"total"`
+	Valid     int            `json:"valid"`
+	Invalid   int            `json:"invalid"`
+	Countries map[string]int `json:"countries"`
+
+	// Outcomes counts the validations per country and outcome
+	Outcomes map[string]map[string]int `json:"outcomes"`
+}
+
 //
 type MetricsRegister interface {
 	Register(Event)
+	RegisterLatency(name string, d time.Duration)
+	RegisterCacheHit()
+	RegisterCacheMiss()
+	RegisterCacheEviction()
+	RegisterCacheSize(size int)
+	RegisterBreakerState(state int)
+	RegisterRequest(method string, route string, status int, d time.Duration)
+	RegisterResult(valid bool)
+	RegisterOutcome(countryCode string, outcome string)
+	Stats() Stats
+	Reset()
 }
 
 type InmemMetricsRegister struct {
@@ -27,6 +61,51 @@ func NewInmemMetricsRegister() *InmemMetricsRegister {
 func (imr *InmemMetricsRegister) Register(e Event) {
 }
 
+func (imr *InmemMetricsRegister) RegisterLatency(name string, d time.Duration) {
+}
+
+func (imr *InmemMetricsRegister) RegisterCacheHit() {
+}
+
+func (imr *InmemMetricsRegister) RegisterCacheMiss() {
+}
+
+func (imr *InmemMetricsRegister) RegisterCacheEviction() {
+}
+
+func (imr *InmemMetricsRegister) RegisterCacheSize(size int) {
+}
+
+func (imr *InmemMetricsRegister) RegisterBreakerState(state int) {
+}
+
+func (imr *InmemMetricsRegister) RegisterRequest(method string, route string, status int, d time.Duration) {
+}
+
+func (imr *InmemMetricsRegister) RegisterResult(valid bool) {
+}
+
+func (imr *InmemMetricsRegister) RegisterOutcome(countryCode string, outcome string) {
+}
+
+func (imr *InmemMetricsRegister) WriteLogRequest(collectionName string, iban *goiban.Iban) {
+}
+
+func (imr *InmemMetricsRegister) LogRequestFromValidationResult(collectionName string, validationResult string) {
+}
+
+func (imr *InmemMetricsRegister) Close() {
+}
+
+func (imr *InmemMetricsRegister) Stats() Stats {
+	return Stats{Countries: map[string]int{}, Outcomes: map[string]map[string]int{}}
+}
+
+func (imr *InmemMetricsRegister) Reset() {
+}
+
+// IbanToEvent creates the metrics event for iban. Events only carry the
+// country code, the IBAN itself never leaves the service.
 func IbanToEvent(iban *goiban.Iban) Event {
 	return Event{
 		Country: iban.GetCountryCode(),
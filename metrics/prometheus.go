@@ -0,0 +1,167 @@
+// +build !no_metrics
+
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/fourcube/goiban"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// PrometheusMetrics exposes validation counters in the Prometheus exposition format
+type PrometheusMetrics struct {
+	registry    *prometheus.Registry
+	validations prometheus.Counter
+	results     *prometheus.CounterVec
+	countries   *prometheus.CounterVec
+	lookups     *prometheus.CounterVec
+	durations   *prometheus.HistogramVec
+	cache       *prometheus.CounterVec
+	evictions   prometheus.Counter
+	cacheSize   prometheus.Gauge
+	breaker     prometheus.Gauge
+	requests    *prometheus.CounterVec
+	errors      *prometheus.CounterVec
+	latencies   *prometheus.HistogramVec
+}
+
+func NewPrometheusMetrics() *PrometheusMetrics {
+	pm := &PrometheusMetrics{
+		registry: prometheus.NewRegistry(),
+		validations: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "goiban",
+			Name:      "validations_total",
+			Help:      "Total number of IBAN validations.",
+		}),
+		results: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "goiban",
+			Name:      "validation_results_total",
+			Help:      "Number of IBAN validations by result.",
+		}, []string{"result"}),
+		countries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "goiban",
+			Name:      "country_validations_total",
+			Help:      "Number of IBAN validations by country.",
+		}, []string{"country_code"}),
+		lookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "goiban",
+			Name:      "lookups_total",
+			Help:      "Number of database lookups by type.",
+		}, []string{"type"}),
+		durations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "goiban",
+			Name:      "operation_duration_seconds",
+			Help:      "Duration of validations and database lookups.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"operation"}),
+		cache: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "goiban",
+			Name:      "cache_lookups_total",
+			Help:      "Number of validation cache lookups by result.",
+		}, []string{"result"}),
+		evictions: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "goiban",
+			Name:      "cache_evictions_total",
+			Help:      "Number of results evicted from the full validation cache.",
+		}),
+		cacheSize: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "goiban",
+			Name:      "cache_entries",
+			Help:      "Number of results held by the in-memory validation cache.",
+		}),
+		breaker: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "goiban",
+			Name:      "db_breaker_state",
+			Help:      "State of the database circuit breaker: 0 closed, 1 half-open, 2 open.",
+		}),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "goiban",
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests by method and route.",
+		}, []string{"method", "route"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "goiban",
+			Name:      "http_request_errors_total",
+			Help:      "Number of HTTP requests answered with a server error by method and route.",
+		}, []string{"method", "route"}),
+		latencies: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "goiban",
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of HTTP requests by method and route.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"method", "route"}),
+	}
+
+	pm.registry.MustRegister(pm.validations, pm.results, pm.countries, pm.lookups, pm.durations, pm.cache, pm.evictions, pm.cacheSize, pm.breaker,
+		pm.requests, pm.errors, pm.latencies)
+	return pm
+}
+
+// RegisterValidation counts a validation. countryCode is only set when the
+// IBAN could be parsed, so unparseable input doesn't create new label values.
+func (pm *PrometheusMetrics) RegisterValidation(countryCode string, result *goiban.ValidationResult) {
+	pm.validations.Inc()
+
+	if result.Valid {
+		pm.results.WithLabelValues("valid").Inc()
+	} else {
+		pm.results.WithLabelValues("invalid").Inc()
+	}
+
+	if countryCode != "" {
+		pm.countries.WithLabelValues(countryCode).Inc()
+	}
+}
+
+// RegisterLookup counts a database lookup, kind is either "bic" or "bank_code"
+func (pm *PrometheusMetrics) RegisterLookup(kind string) {
+	pm.lookups.WithLabelValues(kind).Inc()
+}
+
+// RegisterLatency records the duration of an operation
+func (pm *PrometheusMetrics) RegisterLatency(name string, d time.Duration) {
+	pm.durations.WithLabelValues(name).Observe(d.Seconds())
+}
+
+// RegisterCacheHit counts a validation result served from the cache
+func (pm *PrometheusMetrics) RegisterCacheHit() {
+	pm.cache.WithLabelValues("hit").Inc()
+}
+
+// RegisterCacheMiss counts a validation result that was not cached
+func (pm *PrometheusMetrics) RegisterCacheMiss() {
+	pm.cache.WithLabelValues("miss").Inc()
+}
+
+// RegisterCacheEviction counts a result evicted from the full cache
+func (pm *PrometheusMetrics) RegisterCacheEviction() {
+	pm.evictions.Inc()
+}
+
+// RegisterCacheSize records the number of cached results
+func (pm *PrometheusMetrics) RegisterCacheSize(size int) {
+	pm.cacheSize.Set(float64(size))
+}
+
+// RegisterRequest counts an HTTP request and observes its duration by
+// method and route template. Server errors (5xx) are counted separately.
+func (pm *PrometheusMetrics) RegisterRequest(method string, route string, status int, d time.Duration) {
+	pm.requests.WithLabelValues(method, route).Inc()
+	pm.latencies.WithLabelValues(method, route).Observe(d.Seconds())
+
+	if status >= 500 {
+		pm.errors.WithLabelValues(method, route).Inc()
+	}
+}
+
+// RegisterBreakerState records the state of the database circuit breaker
+func (pm *PrometheusMetrics) RegisterBreakerState(state int) {
+	pm.breaker.Set(float64(state))
+}
+
+func (pm *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	promhttp.HandlerFor(pm.registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+}
@@ -0,0 +1,48 @@
+// +build no_metrics
+
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/fourcube/goiban"
+)
+
+// PrometheusMetrics exposes validation counters in the Prometheus exposition format
+type PrometheusMetrics struct {
+}
+
+func NewPrometheusMetrics() *PrometheusMetrics {
+	return &PrometheusMetrics{}
+}
+
+func (pm *PrometheusMetrics) RegisterValidation(countryCode string, result *goiban.ValidationResult) {
+}
+
+func (pm *PrometheusMetrics) RegisterLookup(kind string) {
+}
+
+func (pm *PrometheusMetrics) RegisterLatency(name string, d time.Duration) {
+}
+
+func (pm *PrometheusMetrics) RegisterCacheHit() {
+}
+
+func (pm *PrometheusMetrics) RegisterCacheMiss() {
+}
+
+func (pm *PrometheusMetrics) RegisterCacheEviction() {
+}
+
+func (pm *PrometheusMetrics) RegisterCacheSize(size int) {
+}
+
+func (pm *PrometheusMetrics) RegisterBreakerState(state int) {
+}
+
+func (pm *PrometheusMetrics) RegisterRequest(method string, route string, status int, d time.Duration) {
+}
+
+func (pm *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+}
@@ -0,0 +1,74 @@
+// +build !no_metrics
+
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	goiban "github.com/fourcube/goiban"
+)
+
+// DEFAULT_STATSD_PREFIX is prepended to the names of the statsd metrics
+const DEFAULT_STATSD_PREFIX = "goiban."
+
+// StatsdMetrics counts the events as statsd counters, e.g.
+//
+//	goiban.validations:1|c|#country:DE,env:Live
+//
+// The country and the collection name are sent as DogStatsD tags. The
+// counters are sent over UDP right away, failed sends are dropped.
+type StatsdMetrics struct {
+	Prefix string
+
+	conn net.Conn
+}
+
+// NewStatsdMetrics creates the backend sending to the statsd agent at
+// address (host:port)
+func NewStatsdMetrics(address string, prefix string) (*StatsdMetrics, error) {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StatsdMetrics{Prefix: prefix, conn: conn}, nil
+}
+
+// WriteLogRequest counts the calculation as <prefix>calculations
+func (sm *StatsdMetrics) WriteLogRequest(collectionName string, iban *goiban.Iban) {
+	sm.count("calculations", collectionName, IbanToEvent(iban))
+}
+
+// LogRequestFromValidationResult counts the validation as
+// <prefix>validations
+func (sm *StatsdMetrics) LogRequestFromValidationResult(collectionName string, validationResult string) {
+	var result goiban.ValidationResult
+	json.Unmarshal([]byte(validationResult), &result)
+
+	sm.count("validations", collectionName, ValidationResultToEvent(&result))
+}
+
+func (sm *StatsdMetrics) count(name string, collectionName string, event Event) {
+	var tags []string
+	if event.Country != "" {
+		tags = append(tags, "country:"+event.Country)
+	}
+	if collectionName != "" {
+		tags = append(tags, "env:"+collectionName)
+	}
+
+	line := fmt.Sprintf("%v%v:1|c", sm.Prefix, name)
+	if len(tags) > 0 {
+		line += "|#" + strings.Join(tags, ",")
+	}
+
+	sm.conn.Write([]byte(line))
+}
+
+// Close closes the connection to the agent
+func (sm *StatsdMetrics) Close() {
+	sm.conn.Close()
+}
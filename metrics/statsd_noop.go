@@ -0,0 +1,28 @@
+// +build no_metrics
+
+package metrics
+
+import (
+	goiban "github.com/fourcube/goiban"
+)
+
+// DEFAULT_STATSD_PREFIX is prepended to the names of the statsd metrics
+const DEFAULT_STATSD_PREFIX = "goiban."
+
+// StatsdMetrics counts the events as statsd counters
+type StatsdMetrics struct {
+	Prefix string
+}
+
+func NewStatsdMetrics(address string, prefix string) (*StatsdMetrics, error) {
+	return &StatsdMetrics{Prefix: prefix}, nil
+}
+
+func (sm *StatsdMetrics) WriteLogRequest(collectionName string, iban *goiban.Iban) {
+}
+
+func (sm *StatsdMetrics) LogRequestFromValidationResult(collectionName string, validationResult string) {
+}
+
+func (sm *StatsdMetrics) Close() {
+}
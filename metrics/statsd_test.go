@@ -0,0 +1,45 @@
+// +build !no_metrics
+
+package metrics
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	goiban "github.com/fourcube/goiban"
+)
+
+func TestStatsdMetricsSendsCounters(t *testing.T) {
+	agent, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Errorf("failed to listen %v", err)
+		t.FailNow()
+	}
+	defer agent.Close()
+
+	statsd, err := NewStatsdMetrics(agent.LocalAddr().String(), DEFAULT_STATSD_PREFIX)
+	if err != nil {
+		t.Errorf("failed to create the statsd backend %v", err)
+		t.FailNow()
+	}
+	defer statsd.Close()
+
+	result := goiban.NewValidationResult(true, "", "DE89370400440532013000")
+	data, _ := json.Marshal(result)
+	statsd.LogRequestFromValidationResult("Live", string(data))
+
+	buf := make([]byte, 512)
+	agent.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := agent.ReadFrom(buf)
+	if err != nil {
+		t.Errorf("failed to receive the counter %v", err)
+		t.FailNow()
+	}
+
+	expected := "goiban.validations:1|c|#country:DE,env:Live"
+	if string(buf[:n]) != expected {
+		t.Errorf("expected %q, got %q", expected, string(buf[:n]))
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	m "github.com/fourcube/goiban-service/metrics"
+)
+
+// Backends of the validation events, see Config.MetricsBackend
+const (
+	METRICS_BACKEND_INMEM  = "inmem"
+	METRICS_BACKEND_KEEN   = "keen"
+	METRICS_BACKEND_STATSD = "statsd"
+)
+
+// Creates the metrics backend selected in config. Without a selection
+// Keen is used when its credentials are set, like before the backend
+// could be chosen.
+func newMetrics(config *Config) (m.Metrics, error) {
+	backend := config.MetricsBackend
+	if backend == "" {
+		backend = METRICS_BACKEND_INMEM
+		if config.KeenProjectID != "" && config.KeenWriteAPIKey != "" {
+			backend = METRICS_BACKEND_KEEN
+		}
+	}
+
+	switch backend {
+	case METRICS_BACKEND_INMEM:
+		return inmemMetrics, nil
+	case METRICS_BACKEND_KEEN:
+		return &m.KeenMetrics{
+			ProjectID:     config.KeenProjectID,
+			WriteAPIKey:   config.KeenWriteAPIKey,
+			FlushInterval: config.KeenFlushInterval.Duration,
+			BatchSize:     config.KeenBatchSize,
+			Collection:    config.KeenCollection,
+		}, nil
+	case METRICS_BACKEND_STATSD:
+		return m.NewStatsdMetrics(config.StatsdAddress, config.StatsdPrefix)
+	}
+
+	return nil, fmt.Errorf("unknown metrics backend %q", backend)
+}
+
+// Posts a check to Keen and logs a warning when the credentials are
+// rejected. A failed check doesn't stop the service, it keeps validating
+// but the events are lost.
+func checkKeenCredentials(keen *m.KeenMetrics) {
+	err := keen.Check()
+	if err != nil {
+		log.Printf("Warning: Keen credentials check failed, events of project %v will be lost: %v", keen.ProjectID, err)
+		return
+	}
+
+	log.Printf("Keen credentials of project %v verified", keen.ProjectID)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+
+	m "github.com/fourcube/goiban-service/metrics"
+)
+
+func TestNewMetricsSelectsBackend(t *testing.T) {
+	config := defaultConfig()
+
+	backend, err := newMetrics(config)
+	if err != nil || backend != m.Metrics(inmemMetrics) {
+		t.Errorf("expected the in-memory register without Keen credentials, got %T %v", backend, err)
+	}
+
+	config.KeenProjectID, config.KeenWriteAPIKey = "project", "key"
+	backend, err = newMetrics(config)
+	if _, ok := backend.(*m.KeenMetrics); !ok || err != nil {
+		t.Errorf("expected Keen with its credentials, got %T %v", backend, err)
+	}
+
+	config.MetricsBackend = METRICS_BACKEND_STATSD
+	backend, err = newMetrics(config)
+	if _, ok := backend.(*m.StatsdMetrics); !ok || err != nil {
+		t.Errorf("expected statsd, got %T %v", backend, err)
+	}
+	backend.Close()
+
+	config.MetricsBackend = "datadog"
+	if _, err := newMetrics(config); err == nil {
+		t.Errorf("expected an unknown backend to be rejected")
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// STATUS_UNSUPPORTED is the national checksum status of accounts without
+// an implemented check. German account numbers are checked by
+// germanNationalChecksum, their check method depends on the bank.
+const STATUS_UNSUPPORTED = "unsupported"
+
+// nationalChecks holds the national check digit validation per country,
+// called with the BBAN of structurally valid IBANs
+var nationalChecks = map[string]func(bban string) bool{
+	"BE": belgianChecksum,
+	"NL": dutchChecksum,
+	"FR": ribKey,
+	"MC": ribKey,
+}
+
+// nationalCheckApplies restricts the check of a country to the accounts it
+// applies to, other accounts of the country are unsupported
+var nationalCheckApplies = map[string]func(bban string) bool{
+	"NL": elfproefApplies,
+}
+
+// Validates the national check digits contained in the BBAN of iban.
+// Returns STATUS_VALID, STATUS_INVALID or STATUS_UNSUPPORTED.
+func nationalChecksum(iban string) string {
+	if len(iban) < 4 {
+		return STATUS_INVALID
+	}
+
+	check, ok := nationalChecks[iban[:2]]
+	if !ok {
+		return STATUS_UNSUPPORTED
+	}
+
+	if applies, ok := nationalCheckApplies[iban[:2]]; ok && !applies(iban[4:]) {
+		return STATUS_UNSUPPORTED
+	}
+
+	if check(iban[4:]) {
+		return STATUS_VALID
+	}
+	return STATUS_INVALID
+}
+
+// The last two digits of a Belgian account number are the remainder of
+// the first ten digits divided by 97, 97 if the remainder is 0.
+func belgianChecksum(bban string) bool {
+	if len(bban) != 12 || !isDigits(bban) {
+		return false
+	}
+
+	expected := mod97(bban[:10])
+	if expected == 0 {
+		expected = 97
+	}
+
+	actual, _ := strconv.Atoi(bban[10:])
+	return actual == expected
+}
+
+// elfproefBanks are the Dutch banks whose account numbers pass the
+// elfproef. ING numbers, e.g. those of the former Postbank, and the
+// numbers of newer banks don't.
+var elfproefBanks = map[string]bool{
+	"ABNA": true,
+	"ASNB": true,
+	"RABO": true,
+	"RBRB": true,
+	"SNSB": true,
+	"TRIO": true,
+}
+
+// Reports whether the Dutch BBAN belongs to a bank using the elfproef
+func elfproefApplies(bban string) bool {
+	return len(bban) >= 4 && elfproefBanks[bban[:4]]
+}
+
+// Dutch account numbers (after the four letter bank code) pass the
+// "elfproef": the digits weighted 10 to 1 sum up to a multiple of 11.
+func dutchChecksum(bban string) bool {
+	if len(bban) != 14 || !isDigits(bban[4:]) {
+		return false
+	}
+
+	sum := 0
+	for i, r := range bban[4:] {
+		sum += int(r-'0') * (10 - i)
+	}
+
+	return sum%11 == 0
+}
+
+// ribLetters maps the letters of French account numbers to digits
+var ribLetters = strings.NewReplacer(
+	"A", "1", "B", "2", "C", "3", "D", "4", "E", "5", "F", "6", "G", "7", "H", "8", "I", "9",
+	"J", "1", "K", "2", "L", "3", "M", "4", "N", "5", "O", "6", "P", "7", "Q", "8", "R", "9",
+	"S", "2", "T", "3", "U", "4", "V", "5", "W", "6", "X", "7", "Y", "8", "Z", "9",
+)
+
+// A French BBAN (RIB) consists of the bank code (5 digits), branch code
+// (5 digits), account number (11 characters) and the two digit RIB key.
+// With letters replaced by digits the whole RIB is divisible by 97.
+func ribKey(bban string) bool {
+	if len(bban) != 23 {
+		return false
+	}
+
+	rib := ribLetters.Replace(bban)
+	if !isDigits(rib) {
+		return false
+	}
+
+	return mod97(rib) == 0
+}
+
+// Reports whether value only consists of the digits 0-9
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+func TestNationalChecksum(t *testing.T) {
+	cases := map[string]string{
+		"BE68539007547034":            STATUS_VALID,
+		"BE68539007547035":            STATUS_INVALID,
+		"NL91ABNA0417164300":          STATUS_VALID,
+		"NL91ABNA0417164301":          STATUS_INVALID,
+		"NL20INGB0001234567":          STATUS_UNSUPPORTED,
+		"FR1420041010050500013M02606": STATUS_VALID,
+		"FR1420041010050500013M02607": STATUS_INVALID,
+		"DE89370400440532013000":      STATUS_UNSUPPORTED,
+	}
+
+	for iban, expected := range cases {
+		if status := nationalChecksum(iban); status != expected {
+			t.Errorf("expected national checksum %v for %v, got %v", expected, iban, status)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// ValidationRequest is the body of POST /validate. GetBIC and
+// ValidateBankCode fall back to the configured defaults when omitted.
+type ValidationRequest struct {
+	Iban                     string `json:"iban"`
+	GetBIC                   *bool  `json:"getBIC"`
+	ValidateBankCode         *bool  `json:"validateBankCode"`
+	ValidateNationalChecksum bool   `json:"validateNationalChecksum"`
+	IncludeBankAddress       bool   `json:"includeBankAddress"`
+	RequireBIC               bool   `json:"requireBIC"`
+	Suggest                  bool   `json:"suggest"`
+	IncludeSepaReachability  bool   `json:"includeSepaReachability"`
+	IncludeComponents        bool   `json:"includeComponents"`
+	Debug                    bool   `json:"debug"`
+	Full                     bool   `json:"full"`
+	ExpectedCountry          string `json:"expectedCountry"`
+	AccountNumberLength      int    `json:"accountNumberLength"`
+}
+
+// Processes POST requests to the /validate url. The IBAN is read from the
+// body so it doesn't end up in URLs and access logs.
+func postValidationHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	w.Header().Add("Content-Type", "application/json; charset=utf-8")
+	// Allow CORS
+	allowOrigin(w, r)
+
+	var request ValidationRequest
+	err := json.NewDecoder(r.Body).Decode(&request)
+	r.Body.Close()
+
+	if bodyTooLarge(err) {
+		writeBodyTooLarge(w, r)
+		return
+	}
+
+	if err != nil {
+		res, _ := json.MarshalIndent(errorResult(ERROR_INVALID_REQUEST, "Expected a JSON object with an iban.", ""), "", "  ")
+		writeResponse(w, r, http.StatusBadRequest, res)
+		return
+	}
+
+	if exceedsMaxLength(request.Iban) {
+		writeTooLong(w, r)
+		return
+	}
+
+	expectedCountry, ok := parseExpectedCountry(request.ExpectedCountry)
+	if !ok {
+		res, _ := json.MarshalIndent(errorResult(ERROR_INVALID_REQUEST, "Expected a two letter country code as expectedCountry.", request.Iban), "", "  ")
+		writeResponse(w, r, http.StatusBadRequest, res)
+		return
+	}
+
+	if request.AccountNumberLength < 0 {
+		res, _ := json.MarshalIndent(errorResult(ERROR_INVALID_REQUEST, "Expected a positive number as accountNumberLength.", request.Iban), "", "  ")
+		writeResponse(w, r, http.StatusBadRequest, res)
+		return
+	}
+
+	defaults := settings()
+	config := map[string]bool{
+		"validateBankCode":         boolOrDefault(request.ValidateBankCode, defaults.DefaultValidateBankCode),
+		"getBIC":                   boolOrDefault(request.GetBIC, defaults.DefaultGetBIC),
+		"validateNationalChecksum": request.ValidateNationalChecksum,
+		"includeBankAddress":       request.IncludeBankAddress,
+		"requireBIC":               request.RequireBIC,
+		"suggest":                  request.Suggest,
+		"includeSepaReachability":  request.IncludeSepaReachability,
+		"includeComponents":        request.IncludeComponents,
+		"debug":                    request.Debug,
+	}
+
+	if request.Full {
+		enableFull(config)
+	}
+
+	writeValidation(w, r, request.Iban, config, expectedCountry, request.AccountNumberLength)
+}
+
+func boolOrDefault(value *bool, fallback bool) bool {
+	if value == nil {
+		return fallback
+	}
+
+	return *value
+}
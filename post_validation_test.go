@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestPostValidation(t *testing.T) {
+	// German accounts need the bank data for the national check
+	body := `{"iban": "BE68 5390 0754 7034", "validateNationalChecksum": true}`
+	resp, err := http.Post(server.URL+"/validate", "application/json", strings.NewReader(body))
+
+	if err != nil {
+		t.Errorf("failed to validate %v", err)
+		t.FailNow()
+	}
+
+	var result ValidationResponse
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	resp.Body.Close()
+
+	if err != nil {
+		t.Errorf("Expected success %v", err)
+		t.FailNow()
+	}
+
+	if !result.Valid || result.Iban != "BE68 5390 0754 7034" {
+		t.Errorf("unexpected result %v", result)
+	}
+
+	if result.NationalChecksum != STATUS_VALID {
+		t.Errorf("expected the options to be read from the body, got %v", result.NationalChecksum)
+	}
+}
+
+func TestPostValidationRejectsInvalidBody(t *testing.T) {
+	resp, err := http.Post(server.URL+"/validate", "application/json", strings.NewReader(`["DE89370400440532013000"]`))
+
+	if err != nil {
+		t.Errorf("failed to validate %v", err)
+		t.FailNow()
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected status %v, got %v", http.StatusBadRequest, resp.StatusCode)
+	}
+}
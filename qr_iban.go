@@ -0,0 +1,23 @@
+package main
+
+import "strconv"
+
+// Institution identifications of Swiss and Liechtenstein QR-IBANs. Payments
+// to QR-IBANs carry a QR reference and are routed separately.
+const (
+	QR_IID_MIN = 30000
+	QR_IID_MAX = 31999
+)
+
+// Reports whether a CH or LI IBAN of valid length is a QR-IBAN, derived
+// from the institution identification (IID) following the check digits.
+// Returns nil for IBANs of other countries.
+func qrIban(iban string) *bool {
+	if len(iban) < 9 || (iban[:2] != "CH" && iban[:2] != "LI") {
+		return nil
+	}
+
+	iid, err := strconv.Atoi(iban[4:9])
+	isQR := err == nil && iid >= QR_IID_MIN && iid <= QR_IID_MAX
+	return &isQR
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestQRIban(t *testing.T) {
+	cases := map[string]*bool{
+		"CH4431999123000889012":  boolPtr(true),
+		"CH9300762011623852957":  boolPtr(false),
+		"DE89370400440532013000": nil,
+	}
+
+	for iban, expected := range cases {
+		strRes, err := validate(context.Background(), iban, map[string]bool{})
+		if err != nil {
+			t.Errorf("failed to validate %v", err)
+			t.FailNow()
+		}
+
+		var result ValidationResponse
+		json.Unmarshal([]byte(strRes), &result)
+
+		if !result.Valid {
+			t.Errorf("expected %v to be valid, got %v", iban, strRes)
+		}
+
+		if (expected == nil) != (result.QRIban == nil) || (expected != nil && *expected != *result.QRIban) {
+			t.Errorf("unexpected qrIban of %v: %v", iban, strRes)
+		}
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
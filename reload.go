@@ -0,0 +1,111 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ReloadableSettings are the settings which are applied again when the
+// service receives SIGHUP. All other settings require a restart.
+type ReloadableSettings struct {
+	AllowedCountries        []string
+	AllowedOrigins          []string
+	DefaultGetBIC           bool
+	DefaultValidateBankCode bool
+	Maintenance             bool
+	MaintenanceRetryAfter   Duration
+}
+
+// reloadableFields are the Config fields of the ReloadableSettings
+var reloadableFields = map[string]bool{
+	"AllowedCountries":        true,
+	"AllowedOrigins":          true,
+	"DefaultGetBIC":           true,
+	"DefaultValidateBankCode": true,
+	"Maintenance":             true,
+	"MaintenanceRetryAfter":   true,
+}
+
+var (
+	// configPath is the config file given with --config
+	configPath string
+
+	settingsMu sync.RWMutex
+	reloaded   *ReloadableSettings
+)
+
+// Returns the current reloadable settings. Until the config has been
+// reloaded they are the ones of cfg.
+func settings() *ReloadableSettings {
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+
+	if reloaded != nil {
+		return reloaded
+	}
+
+	return reloadableSettings(cfg)
+}
+
+func reloadableSettings(config *Config) *ReloadableSettings {
+	return &ReloadableSettings{
+		AllowedCountries:        config.AllowedCountries,
+		AllowedOrigins:          config.AllowedOrigins,
+		DefaultGetBIC:           config.DefaultGetBIC,
+		DefaultValidateBankCode: config.DefaultValidateBankCode,
+		Maintenance:             config.Maintenance,
+		MaintenanceRetryAfter:   config.MaintenanceRetryAfter,
+	}
+}
+
+// Reads the config again the way main does and applies the reloadable
+// settings at once, so no request sees a mix of old and new settings.
+// Changes of other settings are logged and ignored. The current settings
+// are kept when the config file can't be read.
+func reloadConfig() {
+	if configPath == "" {
+		log.Printf("Not reloading the config, no config file was given")
+		return
+	}
+
+	config := defaultConfig()
+	err := loadConfigFile(config, configPath)
+	if err != nil {
+		log.Printf("Error reloading config file, keeping the current settings: %v", err)
+		return
+	}
+
+	loadEnv(config)
+	loadArgs(config, flag.Args())
+
+	for _, name := range changedSettings(cfg, config) {
+		if !reloadableFields[name] {
+			log.Printf("Ignoring the changed setting %v, it requires a restart", name)
+		}
+	}
+
+	settingsMu.Lock()
+	reloaded = reloadableSettings(config)
+	settingsMu.Unlock()
+
+	log.Printf("Reloaded the config: allowed countries [%v], allowed origins [%v], default getBIC %v, default validateBankCode %v, maintenance %v",
+		strings.Join(config.AllowedCountries, ", "), strings.Join(config.AllowedOrigins, ", "),
+		config.DefaultGetBIC, config.DefaultValidateBankCode, config.Maintenance)
+}
+
+// Returns the names of the Config fields which differ between a and b
+func changedSettings(a *Config, b *Config) []string {
+	var changed []string
+
+	va, vb := reflect.ValueOf(a).Elem(), reflect.ValueOf(b).Elem()
+	for i := 0; i < va.NumField(); i++ {
+		if !reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface()) {
+			changed = append(changed, va.Type().Field(i).Name)
+		}
+	}
+
+	return changed
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestReloadConfig(t *testing.T) {
+	file, _ := ioutil.TempFile("", "goiban-config")
+	defer os.Remove(file.Name())
+
+	file.WriteString(`{"allowedCountries": ["AT"], "allowedOrigins": ["https://openiban.com"], "defaultGetBIC": true, "port": "9999"}`)
+	file.Close()
+
+	defer func(path string, port string) {
+		configPath = path
+		cfg.Port = port
+		reloaded = nil
+	}(configPath, cfg.Port)
+	configPath = file.Name()
+	cfg.Port = "8080"
+
+	reloadConfig()
+
+	current := settings()
+	if len(current.AllowedCountries) != 1 || current.AllowedCountries[0] != "AT" {
+		t.Errorf("expected the reloaded allowed countries, got %v", current.AllowedCountries)
+	}
+
+	if !originAllowed("https://openiban.com") || originAllowed("https://example.com") {
+		t.Errorf("expected the reloaded allowed origins, got %v", current.AllowedOrigins)
+	}
+
+	if !current.DefaultGetBIC || current.DefaultValidateBankCode {
+		t.Errorf("unexpected reloaded defaults %+v", current)
+	}
+
+	if countryAllowed("DE") || !countryAllowed("AT") {
+		t.Errorf("expected only AT to be allowed after the reload")
+	}
+
+	if cfg.Port != "8080" {
+		t.Errorf("expected the port to require a restart, got %v", cfg.Port)
+	}
+}
+
+func TestReloadConfigKeepsSettingsOnError(t *testing.T) {
+	defer func(path string) {
+		configPath = path
+		reloaded = nil
+	}(configPath)
+	configPath = "/nonexistent/goiban-config.json"
+
+	before := settings()
+	reloadConfig()
+
+	if reloaded != nil || settings().DefaultGetBIC != before.DefaultGetBIC {
+		t.Errorf("expected the settings to be kept")
+	}
+}
+
+func TestChangedSettings(t *testing.T) {
+	a, b := defaultConfig(), defaultConfig()
+	b.DBUrl = "root:root@/other"
+	b.AllowedCountries = []string{"DE"}
+
+	changed := changedSettings(a, b)
+	if len(changed) != 2 || changed[0] != "DBUrl" || changed[1] != "AllowedCountries" {
+		t.Errorf("unexpected changed settings %v", changed)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"sync/atomic"
+)
+
+// SCHEMA_PROBE reads the columns every lookup depends on
+const SCHEMA_PROBE = "SELECT bankcode, name, bic, country FROM BANK_DATA LIMIT 1"
+
+// schemaMissing is 1 while the last probe found no usable BANK_DATA table
+var schemaMissing int32
+
+// Runs the schema probe. Returns errDBSchema when the BANK_DATA table or
+// one of its columns doesn't exist, other errors of the database as is. An
+// empty table is fine.
+func probeSchema(ctx context.Context) error {
+	var bankCode, name, bic, country sql.NullString
+	err := withDBRetry(func() error {
+		ctx, cancel := lookupContext(ctx)
+		defer cancel()
+
+		return db.QueryRowContext(ctx, SCHEMA_PROBE).Scan(&bankCode, &name, &bic, &country)
+	})
+
+	if isUndefinedTableError(err) || isUndefinedColumnError(err) {
+		atomic.StoreInt32(&schemaMissing, 1)
+		return errDBSchema
+	}
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	atomic.StoreInt32(&schemaMissing, 0)
+	return nil
+}
+
+// Returns errDBSchema while the table is missing. goiban doesn't report
+// the errors of its lookups, so they are checked before. The probe is
+// repeated until the table has been created.
+func checkSchema(ctx context.Context) error {
+	if atomic.LoadInt32(&schemaMissing) == 0 {
+		return nil
+	}
+
+	return probeSchema(ctx)
+}
+
+// Replaces the driver error of a missing table by errDBSchema
+func schemaError(err error) error {
+	if isUndefinedTableError(err) {
+		atomic.StoreInt32(&schemaMissing, 1)
+		return errDBSchema
+	}
+
+	return err
+}
+
+// States of optional columns
+const (
+	COLUMNS_UNKNOWN int32 = iota
+	COLUMNS_PRESENT
+	COLUMNS_MISSING
+)
+
+// optionalColumns are columns of BANK_DATA not every dataset contains.
+// Whether they exist is probed by the first lookup and remembered, so
+// datasets without them aren't queried for them on every validation.
+type optionalColumns struct {
+	probe string
+	state int32
+}
+
+// Reports whether the columns are known to be missing, lookups of them
+// can be skipped without a connection to the database
+func (oc *optionalColumns) missing() bool {
+	return atomic.LoadInt32(&oc.state) == COLUMNS_MISSING
+}
+
+// Reports whether the columns exist, running the probe unless the result of
+// an earlier one is known. Errors other than a missing column are returned
+// and the probe is repeated by the next lookup.
+func (oc *optionalColumns) exist(ctx context.Context) (bool, error) {
+	switch atomic.LoadInt32(&oc.state) {
+	case COLUMNS_PRESENT:
+		return true, nil
+	case COLUMNS_MISSING:
+		return false, nil
+	}
+
+	err := withDBRetry(func() error {
+		ctx, cancel := lookupContext(ctx)
+		defer cancel()
+
+		rows, err := db.QueryContext(ctx, oc.probe)
+		if err != nil {
+			return err
+		}
+
+		return rows.Close()
+	})
+
+	if isUndefinedColumnError(err) {
+		oc.setMissing()
+		return false, nil
+	}
+	if err == context.DeadlineExceeded {
+		return false, errDBTimeout
+	}
+	if err != nil {
+		return false, schemaError(err)
+	}
+
+	atomic.StoreInt32(&oc.state, COLUMNS_PRESENT)
+	return true, nil
+}
+
+// Remembers that the columns are missing, e.g. after a lookup of them failed
+func (oc *optionalColumns) setMissing() {
+	atomic.StoreInt32(&oc.state, COLUMNS_MISSING)
+}
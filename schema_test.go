@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+func TestIsUndefinedTableError(t *testing.T) {
+	cases := map[error]bool{
+		&mysql.MySQLError{Number: 1146}: true,
+		&mysql.MySQLError{Number: 1054}: false,
+		&pq.Error{Code: "42P01"}:        true,
+		&pq.Error{Code: "42703"}:        false,
+		errDBTimeout:                    false,
+	}
+
+	for err, expected := range cases {
+		if isUndefinedTableError(err) != expected {
+			t.Errorf("expected isUndefinedTableError(%v) to be %v", err, expected)
+		}
+	}
+}
+
+func TestSchemaError(t *testing.T) {
+	defer atomic.StoreInt32(&schemaMissing, 0)
+
+	if err := schemaError(errDBTimeout); err != errDBTimeout {
+		t.Errorf("expected other errors to be kept, got %v", err)
+	}
+
+	if err := schemaError(&mysql.MySQLError{Number: 1146}); err != errDBSchema {
+		t.Errorf("expected %v, got %v", errDBSchema, err)
+	}
+
+	if atomic.LoadInt32(&schemaMissing) != 1 {
+		t.Errorf("expected the missing table to be remembered")
+	}
+
+	response, status := lookupError(errDBSchema, "")
+	if response.ErrorCode != ERROR_DB_SCHEMA || status != http.StatusInternalServerError {
+		t.Errorf("expected %v with HTTP 500, got %v %v", ERROR_DB_SCHEMA, response.ErrorCode, status)
+	}
+}
+
+func TestCheckSchemaRecovers(t *testing.T) {
+	if db.Ping() != nil {
+		t.Skip("database is not available")
+	}
+	defer atomic.StoreInt32(&schemaMissing, 0)
+
+	// the probe is repeated and finds the existing table
+	atomic.StoreInt32(&schemaMissing, 1)
+	if err := checkSchema(context.Background()); err != nil {
+		t.Errorf("failed to probe the schema %v", err)
+	}
+
+	if atomic.LoadInt32(&schemaMissing) != 0 {
+		t.Errorf("expected the table to be found")
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/fourcube/goiban"
+)
+
+// SELECT_SEPA_REACHABILITY reads the optional SEPA columns of a bank. Not
+// every dataset contains them, the columns may be missing or null.
+const SELECT_SEPA_REACHABILITY = "SELECT sepa_sct, sepa_sdd FROM BANK_DATA WHERE bankcode = ? AND country = ? LIMIT 1"
+
+// SepaReachability tells whether the bank accepts SEPA Credit Transfers
+// (SCT) and SEPA Direct Debits (SDD). Unknown values are omitted.
+type SepaReachability struct {
+	SCT *bool `json:"sct,omitempty"`
+	SDD *bool `json:"sdd,omitempty"`
+}
+
+// sepaReachabilityColumns tells whether the dataset contains the SEPA columns
+var sepaReachabilityColumns = &optionalColumns{probe: "SELECT sepa_sct, sepa_sdd FROM BANK_DATA LIMIT 1"}
+
+// Looks up the SEPA reachability of the bank of result. Returns nil when
+// the dataset has no information about the bank. While the circuit breaker
+// is open the lookup fails right away.
+func sepaReachability(ctx context.Context, countryCode string, result *goiban.ValidationResult) (*SepaReachability, error) {
+	if result.BankData.BankCode == "" || sepaReachabilityColumns.missing() {
+		return nil, nil
+	}
+
+	err := dbBreaker.allow()
+	if err != nil {
+		return nil, err
+	}
+
+	reachability, err := lookupSepaReachability(ctx, countryCode, result)
+	dbBreaker.record(err)
+
+	return reachability, err
+}
+
+func lookupSepaReachability(ctx context.Context, countryCode string, result *goiban.ValidationResult) (*SepaReachability, error) {
+	slot, err := acquireDBSlot()
+	if err != nil {
+		return nil, err
+	}
+	defer slot.release()
+
+	exist, err := sepaReachabilityColumns.exist(ctx)
+	if err != nil || !exist {
+		return nil, err
+	}
+
+	var sct, sdd sql.NullBool
+	err = withDBRetry(func() error {
+		ctx, cancel := lookupContext(ctx)
+		defer cancel()
+
+		return db.QueryRowContext(ctx, SELECT_SEPA_REACHABILITY, result.BankData.BankCode, countryCode).Scan(&sct, &sdd)
+	})
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if isUndefinedColumnError(err) {
+		sepaReachabilityColumns.setMissing()
+		return nil, nil
+	}
+	if err == context.DeadlineExceeded {
+		return nil, errDBTimeout
+	}
+	if err != nil {
+		return nil, schemaError(err)
+	}
+
+	if !sct.Valid && !sdd.Valid {
+		return nil, nil
+	}
+
+	reachability := &SepaReachability{}
+	if sct.Valid {
+		reachability.SCT = &sct.Bool
+	}
+	if sdd.Valid {
+		reachability.SDD = &sdd.Bool
+	}
+
+	return reachability, nil
+}
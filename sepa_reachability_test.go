@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fourcube/goiban"
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+)
+
+func TestSepaReachabilityWithoutBankCode(t *testing.T) {
+	result := goiban.NewValidationResult(true, "", "DE89370400440532013000")
+
+	// nothing is looked up without a bank code
+	reachability, err := sepaReachability(context.Background(), "DE", result)
+	if err != nil || reachability != nil {
+		t.Errorf("expected no reachability, got %v %v", reachability, err)
+	}
+}
+
+func TestSepaReachability(t *testing.T) {
+	if db.Ping() != nil {
+		t.Skip("database is not available")
+	}
+
+	result := goiban.NewValidationResult(true, "", "DE89370400440532013000")
+	result.BankData.BankCode = "37040044"
+
+	// datasets without the columns are not an error
+	if _, err := sepaReachability(context.Background(), "DE", result); err != nil {
+		t.Errorf("failed to look up the SEPA reachability %v", err)
+	}
+}
+
+func TestSepaReachabilityColumnsAreProbedOnce(t *testing.T) {
+	defer func(cb *circuitBreaker) {
+		dbBreaker = cb
+		atomic.StoreInt32(&sepaReachabilityColumns.state, COLUMNS_UNKNOWN)
+	}(dbBreaker)
+
+	dbBreaker = newCircuitBreaker(1, time.Hour)
+	dbBreaker.allow()
+	dbBreaker.record(errDBTimeout)
+
+	result := goiban.NewValidationResult(true, "", "DE89370400440532013000")
+	result.BankData.BankCode = "37040044"
+
+	// the lookup goes through the circuit breaker
+	if _, err := sepaReachability(context.Background(), "DE", result); err != errCircuitOpen {
+		t.Errorf("expected the open breaker to fail the lookup, got %v", err)
+	}
+
+	// datasets known to lack the columns aren't queried at all
+	sepaReachabilityColumns.setMissing()
+	if reachability, err := sepaReachability(context.Background(), "DE", result); err != nil || reachability != nil {
+		t.Errorf("expected no reachability without the columns, got %v %v", reachability, err)
+	}
+}
+
+func TestIsUndefinedColumnError(t *testing.T) {
+	cases := map[error]bool{
+		&mysql.MySQLError{Number: 1054}: true,
+		&mysql.MySQLError{Number: 1045}: false,
+		&pq.Error{Code: "42703"}:        true,
+		&pq.Error{Code: "42P01"}:        false,
+		errDBTimeout:                    false,
+	}
+
+	for err, expected := range cases {
+		if isUndefinedColumnError(err) != expected {
+			t.Errorf("expected isUndefinedColumnError(%v) to be %v", err, expected)
+		}
+	}
+}
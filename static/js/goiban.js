@@ -138,7 +138,7 @@ var goiban = {
 	*/
   getCountryCodes: function (callback) {
     $.ajax({
-      url: '/countries',
+      url: 'countries',
       success: function(data) {
         callback(data);
       }
@@ -148,7 +148,7 @@ var goiban = {
 
 		$.ajax({
 			data: {"validateBankCode":true, "getBIC": true},
-			url: '/validate/' + iban,
+			url: 'validate/' + iban,
 			success: function(data) {
 				callback(data);
 			},
@@ -158,7 +158,7 @@ var goiban = {
 	},
   calculate: function(countryCode, bankCode, accountNumber, callback) {
     $.ajax({
-			url: '/v2/calculate/' + countryCode + "/" + bankCode + "/" + accountNumber,
+			url: 'v2/calculate/' + countryCode + "/" + bankCode + "/" + accountNumber,
 			success: function(data) {
 				callback(data);
 			},
@@ -186,7 +186,7 @@ var goiban = {
 
     chartData.labels = _.chain(data)
       .pairs()
-      .filter(function (k) { return k[0].length > 0; })
+      .filter(function (k) { return /^[A-Z]{2}$/.test(k[0]); })
       .sortBy(function (k) { return -k[1]; })
       .take(8)
       .map(function (k) { return k[0]; })
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// embeddedStatic holds the static frontend, so the binary can serve it
+// without the static directory next to it
+//
+//go:embed static
+var embeddedStatic embed.FS
+
+// Returns the files of the static frontend. An external directory is
+// preferred when one is configured, e.g. to edit the files during local
+// development, otherwise the embedded files are served.
+func staticFileSystem(dir string) http.FileSystem {
+	if dir != "" {
+		return http.Dir(dir)
+	}
+
+	files, _ := fs.Sub(embeddedStatic, "static")
+	return http.FS(files)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStaticFilesAreEmbedded(t *testing.T) {
+	server := httptest.NewServer(http.FileServer(staticFileSystem("")))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/imprint.html")
+	if err != nil {
+		t.Errorf("failed to get the imprint %v", err)
+		t.FailNow()
+	}
+	defer resp.Body.Close()
+
+	embedded, _ := io.ReadAll(resp.Body)
+	local, _ := os.ReadFile("static/imprint.html")
+
+	if resp.StatusCode != http.StatusOK || string(embedded) != string(local) {
+		t.Errorf("expected the embedded imprint, got %v", resp.StatusCode)
+	}
+}
+
+func TestStaticDirIsPreferred(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "index.html"), []byte("local development"), 0644)
+
+	server := httptest.NewServer(http.FileServer(staticFileSystem(dir)))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/")
+	if err != nil {
+		t.Errorf("failed to get the index %v", err)
+		t.FailNow()
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "local development") {
+		t.Errorf("expected the files of the static directory, got %v", string(body))
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// Processes requests to the /stats url. Summarizes the validations held
+// by the in-memory metrics register.
+func statsHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	// Allow CORS
+	allowOrigin(w, r)
+
+	data, err := json.MarshalIndent(inmemMetrics.Stats(), "", "  ")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, data)
+}
+
+// Processes POST requests to the /metrics/reset url. Clears the in-memory
+// metrics register so integration tests start from a clean slate. The
+// route is never available in the Live environment.
+func metricsResetHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	if ENV == "Live" {
+		notFoundHandler(w, r)
+		return
+	}
+
+	inmemMetrics.Reset()
+	w.WriteHeader(http.StatusNoContent)
+}
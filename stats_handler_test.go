@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	m "github.com/fourcube/goiban-service/metrics"
+)
+
+func TestStatsCountValidations(t *testing.T) {
+	c.Delete(cacheKey("DE89370400440532013001", map[string]bool{}))
+	validate(context.Background(), "DE89370400440532013001", map[string]bool{})
+
+	resp, err := http.Get(server.URL + "/stats")
+	if err != nil {
+		t.Errorf("failed to get stats %v", err)
+		t.FailNow()
+	}
+
+	var stats m.Stats
+	err = json.NewDecoder(resp.Body).Decode(&stats)
+	resp.Body.Close()
+
+	if err != nil {
+		t.Errorf("failed to decode stats %v", err)
+		t.FailNow()
+	}
+
+	if stats.Invalid == 0 || stats.Total != stats.Valid+stats.Invalid {
+		t.Errorf("unexpected stats %v", stats)
+	}
+}
+
+func TestStatsCountOutcomes(t *testing.T) {
+	inmemMetrics.Reset()
+
+	for _, iban := range []string{"DE89370400440532013000", "DE89370400440532013001", "DE89-3704", "1"} {
+		c.Delete(cacheKey(iban, map[string]bool{}))
+		validate(context.Background(), iban, map[string]bool{})
+	}
+
+	resp, err := http.Get(server.URL + "/stats")
+	if err != nil {
+		t.Errorf("failed to get stats %v", err)
+		t.FailNow()
+	}
+
+	var stats m.Stats
+	err = json.NewDecoder(resp.Body).Decode(&stats)
+	resp.Body.Close()
+
+	if err != nil {
+		t.Errorf("failed to decode stats %v", err)
+		t.FailNow()
+	}
+
+	de := stats.Outcomes["DE"]
+	if de[m.OUTCOME_VALID] != 1 || de[m.OUTCOME_INVALID] != 1 || de[m.OUTCOME_UNPARSEABLE] != 1 {
+		t.Errorf("unexpected outcomes of DE %v", stats.Outcomes)
+	}
+
+	if stats.Outcomes[m.UNKNOWN_COUNTRY][m.OUTCOME_UNPARSEABLE] != 1 {
+		t.Errorf("expected the short input in the unknown bucket, got %v", stats.Outcomes)
+	}
+}
+
+func TestDisabledMetricsRetainNothing(t *testing.T) {
+	defer func(enabled bool) { cfg.Metrics = enabled }(cfg.Metrics)
+	cfg.Metrics = false
+
+	before := inmemMetrics.Stats()
+	c.Delete(cacheKey("DE89370400440532013001", map[string]bool{}))
+	validate(context.Background(), "DE89370400440532013001", map[string]bool{})
+
+	if after := inmemMetrics.Stats(); after.Total != before.Total {
+		t.Errorf("expected no validations to be counted, got %v instead of %v", after.Total, before.Total)
+	}
+}
+
+func TestMetricsReset(t *testing.T) {
+	validate(context.Background(), "DE89370400440532013001", map[string]bool{})
+	inmemMetrics.RegisterResult(false)
+
+	resp, err := http.Post(server.URL+"/metrics/reset", "", nil)
+	if err != nil {
+		t.Errorf("failed to reset metrics %v", err)
+		t.FailNow()
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("expected HTTP 204, got %v", resp.StatusCode)
+	}
+
+	if stats := inmemMetrics.Stats(); stats.Total != 0 {
+		t.Errorf("expected no validations after the reset, got %v", stats)
+	}
+}
+
+func TestMetricsResetUnavailableInLive(t *testing.T) {
+	defer func(env string) { ENV = env }(ENV)
+	ENV = "Live"
+
+	inmemMetrics.RegisterResult(false)
+
+	resp, err := http.Post(server.URL+"/metrics/reset", "", nil)
+	if err != nil {
+		t.Errorf("failed to post %v", err)
+		t.FailNow()
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNotFound || inmemMetrics.Stats().Total == 0 {
+		t.Errorf("expected the reset to be unavailable, got %v", resp.StatusCode)
+	}
+}
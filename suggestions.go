@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/fourcube/goiban"
+)
+
+// MAX_SUGGESTIONS limits the number of corrections returned for an IBAN
+const MAX_SUGGESTIONS = 5
+
+// Adds corrections of the IBAN to the rendered validation result strRes
+// when its checksum is wrong
+func addSuggestions(strRes string) string {
+	var result ValidationResponse
+	json.Unmarshal([]byte(strRes), &result)
+
+	if result.ErrorCode != ERROR_BAD_CHECKSUM {
+		return strRes
+	}
+
+	result.Suggestions = suggestIbans(normalizeIban(result.Iban))
+
+	res, _ := json.MarshalIndent(result, "", "  ")
+	return string(res)
+}
+
+// Returns IBANs which differ from iban by swapping two adjacent characters
+// or by replacing a single character and pass the structure, length and
+// checksum checks. Transpositions are the more common typo, they come
+// first. The country code is kept, digits are only replaced by digits and
+// letters by letters. At most MAX_SUGGESTIONS candidates are returned.
+func suggestIbans(iban string) []string {
+	suggestions := []string{}
+	if len(iban) < 5 {
+		return suggestions
+	}
+
+	seen := map[string]bool{iban: true}
+	candidate := []byte(iban)
+
+	try := func() bool {
+		value := string(candidate)
+		if !seen[value] && validCandidate(value) {
+			suggestions = append(suggestions, value)
+		}
+		seen[value] = true
+
+		return len(suggestions) >= MAX_SUGGESTIONS
+	}
+
+	for i := 2; i < len(candidate)-1; i++ {
+		candidate[i], candidate[i+1] = candidate[i+1], candidate[i]
+		done := try()
+		candidate[i], candidate[i+1] = candidate[i+1], candidate[i]
+
+		if done {
+			return suggestions
+		}
+	}
+
+	for i := 2; i < len(candidate); i++ {
+		original := candidate[i]
+
+		first, last := byte('0'), byte('9')
+		if original >= 'A' && original <= 'Z' {
+			first, last = 'A', 'Z'
+		}
+
+		for replacement := first; replacement <= last; replacement++ {
+			candidate[i] = replacement
+			if try() {
+				return suggestions
+			}
+		}
+
+		candidate[i] = original
+	}
+
+	return suggestions
+}
+
+func validCandidate(iban string) bool {
+	if ibanChecksum(iban) != 1 || !goiban.IsParseable(iban).Valid {
+		return false
+	}
+
+	countryCode := goiban.ExtractCountryCode(iban)
+	return validateLength(countryCode, iban) == nil && validateBbanFormat(countryCode, iban) == nil
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestSuggestIbansFindsTransposition(t *testing.T) {
+	suggestions := suggestIbans("DE89370400440532031000")
+
+	found := false
+	for _, suggestion := range suggestions {
+		if suggestion == "DE89370400440532013000" {
+			found = true
+		}
+
+		if ibanChecksum(suggestion) != 1 {
+			t.Errorf("expected %v to have a valid checksum", suggestion)
+		}
+	}
+
+	if !found || len(suggestions) > MAX_SUGGESTIONS {
+		t.Errorf("expected the transposition to be corrected, got %v", suggestions)
+	}
+}
+
+func TestSuggestOnlyOnChecksumFailure(t *testing.T) {
+	cases := map[string]bool{
+		"DE89370400440532031000": true,
+		"DE89370400440532013000": false,
+	}
+
+	for iban, suggested := range cases {
+		res, err := http.Get(server.URL + "/validate/" + iban + "?suggest=true")
+		if err != nil {
+			t.Errorf("failed to validate %v", err)
+			t.FailNow()
+		}
+
+		var result ValidationResponse
+		json.NewDecoder(res.Body).Decode(&result)
+		res.Body.Close()
+
+		if (len(result.Suggestions) > 0) != suggested {
+			t.Errorf("expected suggestions for %v to be %v, got %v", iban, suggested, result.Suggestions)
+		}
+	}
+}
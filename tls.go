@@ -0,0 +1,56 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// Checks that the TLS certificate and key are either both set or both
+// unset, and that they can be loaded. Called at startup to fail fast.
+func checkTLSConfig(config *Config) error {
+	if config.TLSCert == "" && config.TLSKey == "" {
+		if config.TLSRedirectPort != "" {
+			return errors.New("GOIBAN_TLS_REDIRECT_PORT requires GOIBAN_TLS_CERT and GOIBAN_TLS_KEY")
+		}
+		return nil
+	}
+
+	if config.TLSCert == "" || config.TLSKey == "" {
+		return errors.New("both GOIBAN_TLS_CERT and GOIBAN_TLS_KEY must be set to enable TLS")
+	}
+
+	if _, unix := unixSocketPath(config.Port); unix && config.TLSRedirectPort != "" {
+		return errors.New("GOIBAN_TLS_REDIRECT_PORT can't redirect to a Unix socket")
+	}
+
+	_, err := tls.LoadX509KeyPair(config.TLSCert, config.TLSKey)
+	if err != nil {
+		return fmt.Errorf("cannot load TLS certificate: %v", err)
+	}
+
+	return nil
+}
+
+// Reports whether the service is served via HTTPS
+func tlsEnabled(config *Config) bool {
+	return config.TLSCert != "" && config.TLSKey != ""
+}
+
+// Redirects every request to the same URL on the HTTPS listener at port
+func httpsRedirectHandler(port string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+
+		if port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckTLSConfigRequiresCertAndKey(t *testing.T) {
+	config := defaultConfig()
+	if err := checkTLSConfig(config); err != nil {
+		t.Errorf("expected plaintext to be valid, got %v", err)
+	}
+
+	config.TLSCert = "cert.pem"
+	if err := checkTLSConfig(config); err == nil {
+		t.Errorf("expected an error when only the certificate is set")
+	}
+
+	config.TLSCert = ""
+	config.TLSKey = "key.pem"
+	if err := checkTLSConfig(config); err == nil {
+		t.Errorf("expected an error when only the key is set")
+	}
+}
+
+func TestHttpsRedirect(t *testing.T) {
+	cases := map[string]string{
+		"443":  "https://openiban.com/validate/DE89370400440532013000?getBIC=true",
+		"8443": "https://openiban.com:8443/validate/DE89370400440532013000?getBIC=true",
+	}
+
+	for port, expected := range cases {
+		req := httptest.NewRequest("GET", "http://openiban.com:8080/validate/DE89370400440532013000?getBIC=true", nil)
+		rec := httptest.NewRecorder()
+		httpsRedirectHandler(port).ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusMovedPermanently {
+			t.Errorf("expected status %v, got %v", http.StatusMovedPermanently, rec.Code)
+		}
+
+		if location := rec.Header().Get("Location"); location != expected {
+			t.Errorf("expected redirect to %v, got %v", expected, location)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TRACING_SERVICE_NAME is the service.name of the exported spans
+const TRACING_SERVICE_NAME = "goiban-service"
+
+// tracer creates the spans of the service. Until tracing is set up it
+// uses the global no-op provider, so the spans cost next to nothing.
+var tracer = otel.Tracer("github.com/fourcube/goiban-service")
+
+// Sets up the OTLP/HTTP exporter as global tracer provider. The endpoint
+// and headers are read from the standard OTEL_EXPORTER_OTLP_* environment
+// variables. The returned function flushes the remaining spans.
+func startTracing() (func(context.Context) error, error) {
+	exporter, err := otlptracehttp.New(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", TRACING_SERVICE_NAME),
+		attribute.String("service.version", version),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return provider.Shutdown, nil
+}
+
+// Starts a server span per request. The trace context of incoming W3C
+// traceparent headers is continued. IBANs in the URL are masked like in
+// the access log.
+func tracingHandler(h http.Handler) http.Handler {
+	propagator := propagation.TraceContext{}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracer.Start(ctx, "HTTP "+r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.method", r.Method),
+				attribute.String("http.target", maskedRequestURI(r)),
+			))
+		defer span.End()
+
+		lw := &accessLogResponseWriter{ResponseWriter: w, status: http.StatusOK}
+		h.ServeHTTP(lw, r.WithContext(ctx))
+
+		span.SetAttributes(attribute.Int("http.status_code", lw.status))
+		if lw.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(lw.status))
+		}
+	})
+}
+
+// Starts the span of a single validation
+func startValidationSpan(ctx context.Context, iban string) (context.Context, trace.Span) {
+	countryCode := ""
+	if len(iban) >= 2 {
+		countryCode = iban[:2]
+	}
+
+	return tracer.Start(ctx, "validate", trace.WithAttributes(
+		attribute.String("goiban.country_code", countryCode),
+		attribute.Bool("goiban.db_lookup", false),
+	))
+}
+
+// Starts the span of a bank data lookup and marks the enclosing
+// validation span as one which queried the database
+func startLookupSpan(ctx context.Context, lookup string) (context.Context, trace.Span) {
+	trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("goiban.db_lookup", true))
+
+	return tracer.Start(ctx, "lookup "+lookup,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("goiban.lookup", lookup)))
+}
+
+// Ends the span, recording err as failure
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracingHandlerContinuesTrace(t *testing.T) {
+	var traceID string
+	handler := tracingHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceID = trace.SpanFromContext(r.Context()).SpanContext().TraceID().String()
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	req := httptest.NewRequest("GET", "/validate/DE89370400440532013000", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	if traceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("expected the trace of the traceparent header to be continued, got %v", traceID)
+	}
+
+	if rec.Code != http.StatusTeapot {
+		t.Errorf("expected the status to be passed on, got %v", rec.Code)
+	}
+}
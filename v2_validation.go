@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/fourcube/goiban"
+	"github.com/julienschmidt/httprouter"
+)
+
+// Statuses of a single check in a V2ValidationResult
+const (
+	STATUS_VALID     = "valid"
+	STATUS_INVALID   = "invalid"
+	STATUS_SKIPPED   = "skipped"
+	STATUS_UNKNOWN   = "unknown"
+	STATUS_FOUND     = "found"
+	STATUS_NOT_FOUND = "not_found"
+)
+
+// V2ValidationResult is the response of /v2/validate. Every part of the
+// validation is reported separately.
+type V2ValidationResult struct {
+	Valid       bool        `json:"valid"`
+	Iban        string      `json:"iban"`
+	CountryCode string      `json:"countryCode,omitempty"`
+	CountryName string      `json:"countryName,omitempty"`
+	ErrorCode   string      `json:"errorCode,omitempty"`
+	Structure   V2Check     `json:"structure"`
+	Checksum    V2Check     `json:"checksum"`
+	BankCode    V2Check     `json:"bankCode"`
+	National    V2Check     `json:"nationalChecksum"`
+	Bic         V2BicResult `json:"bic"`
+}
+
+// V2Check is the outcome of a single check
+type V2Check struct {
+	Status   string   `json:"status"`
+	Messages []string `json:"messages,omitempty"`
+}
+
+// V2BicResult is the outcome of the BIC lookup
+type V2BicResult struct {
+	Status   string           `json:"status"`
+	BankData *goiban.BankInfo `json:"bankData,omitempty"`
+	Source   string           `json:"source,omitempty"`
+}
+
+// Processes requests to the /v2/validate/ url
+func validationHandlerV2(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	// Allow CORS
+	allowOrigin(w, r)
+
+	iban := ps.ByName("iban")
+	if exceedsMaxLength(iban) {
+		writeTooLong(w, r)
+		return
+	}
+
+	config := validationConfig(r)
+
+	if len(iban) == 0 {
+		res, _ := json.MarshalIndent(errorResult(ERROR_EMPTY_INPUT, "Empty request.", iban), "", "  ")
+		writeResponse(w, r, http.StatusBadRequest, res)
+		return
+	}
+
+	// the cached v1 result is the source of the bank data
+	strRes, err := validate(r.Context(), iban, config)
+	if err != nil {
+		result, status := lookupError(err, iban)
+		res, _ := json.MarshalIndent(result, "", "  ")
+		writeResponse(w, r, status, res)
+		return
+	}
+
+	var result ValidationResponse
+	json.Unmarshal([]byte(strRes), &result)
+
+	v2 := newV2ValidationResult(&result, config)
+	data, err := json.MarshalIndent(v2, "", "  ")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeCacheableResponse(w, r, data, v2.Valid)
+}
+
+// Assembles the v2 response from the result of validate, which echoes the
+// input without a leading "IBAN" label
+func newV2ValidationResult(result *ValidationResponse, config map[string]bool) *V2ValidationResult {
+	iban := compactIban(result.Iban)
+
+	v2 := &V2ValidationResult{
+		Valid:       result.Valid,
+		Iban:        result.Iban,
+		CountryName: result.CountryName,
+		ErrorCode:   result.ErrorCode,
+		Checksum:    V2Check{Status: STATUS_SKIPPED},
+		BankCode:    V2Check{Status: STATUS_SKIPPED},
+		National:    V2Check{Status: STATUS_SKIPPED},
+		Bic:         V2BicResult{Status: STATUS_SKIPPED},
+	}
+
+	if result.ErrorCode == ERROR_IBAN_TOO_LONG {
+		v2.Structure = V2Check{STATUS_INVALID, result.Messages}
+		return v2
+	}
+
+	if message := invalidCharacters(iban); message != "" {
+		v2.Structure = V2Check{STATUS_INVALID, []string{message}}
+		return v2
+	}
+
+	parserResult := goiban.IsParseable(iban)
+	if !parserResult.Valid {
+		v2.Structure = V2Check{STATUS_INVALID, []string{"Cannot parse as IBAN: " + parserResult.Message}}
+		return v2
+	}
+
+	v2.CountryCode = goiban.ExtractCountryCode(iban)
+
+	if result.ErrorCode == ERROR_COUNTRY_UNSUPPORTED {
+		v2.Structure = V2Check{STATUS_INVALID, result.Messages}
+		return v2
+	}
+
+	lengthResult := validateLength(v2.CountryCode, iban)
+	if lengthResult != nil {
+		v2.Structure = V2Check{STATUS_INVALID, lengthResult.Messages}
+		return v2
+	}
+
+	if formatResult := validateBbanFormat(v2.CountryCode, iban); formatResult != nil {
+		v2.Structure = V2Check{STATUS_INVALID, formatResult.Messages}
+		return v2
+	}
+	v2.Structure = V2Check{Status: STATUS_VALID}
+
+	if ibanChecksum(iban) == 1 {
+		v2.Checksum = V2Check{Status: STATUS_VALID}
+	} else {
+		v2.Checksum = V2Check{STATUS_INVALID, []string{"Invalid IBAN checksum."}}
+	}
+
+	if config["validateBankCode"] {
+		valid, ok := result.CheckResults["bankCode"]
+
+		switch {
+		case !ok:
+			v2.BankCode.Status = STATUS_UNKNOWN
+		case valid:
+			v2.BankCode.Status = STATUS_VALID
+		default:
+			v2.BankCode.Status = STATUS_INVALID
+		}
+	}
+
+	if config["validateNationalChecksum"] && result.NationalChecksum != "" {
+		v2.National.Status = result.NationalChecksum
+	}
+
+	if config["getBIC"] {
+		if result.BankData.Bic != "" {
+			v2.Bic = V2BicResult{STATUS_FOUND, &result.BankData, result.BicSource}
+		} else {
+			v2.Bic = V2BicResult{Status: STATUS_NOT_FOUND}
+		}
+	}
+
+	return v2
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestV2ValidationSeparatesChecks(t *testing.T) {
+	resp, err := http.Get(server.URL + "/v2/validate/DE89370400440532013001")
+
+	if err != nil {
+		t.Errorf("failed to validate iban %v", err)
+		t.FailNow()
+	}
+
+	var res V2ValidationResult
+	json.NewDecoder(resp.Body).Decode(&res)
+	resp.Body.Close()
+
+	if res.Valid {
+		t.Errorf("expected validation to fail")
+	}
+
+	if res.Structure.Status != STATUS_VALID || res.Checksum.Status != STATUS_INVALID {
+		t.Errorf("expected valid structure and invalid checksum, got %v", res)
+	}
+
+	if res.BankCode.Status != STATUS_SKIPPED || res.Bic.Status != STATUS_SKIPPED {
+		t.Errorf("expected bank code and BIC checks to be skipped, got %v", res)
+	}
+}
+
+func TestV2ValidationUnparseable(t *testing.T) {
+	resp, err := http.Get(server.URL + "/v2/validate/D")
+
+	if err != nil {
+		t.Errorf("failed to validate iban %v", err)
+		t.FailNow()
+	}
+
+	var res V2ValidationResult
+	json.NewDecoder(resp.Body).Decode(&res)
+	resp.Body.Close()
+
+	if res.Structure.Status != STATUS_INVALID || res.Checksum.Status != STATUS_SKIPPED {
+		t.Errorf("expected invalid structure and skipped checksum, got %v", res)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/fourcube/goiban"
+)
+
+// ValidationResponse is the rendered result of a validation. It extends
+// goiban.ValidationResult with additional information about the IBAN.
+type ValidationResponse struct {
+	*goiban.ValidationResult
+	CountryName string `json:"countryName,omitempty"`
+	ErrorCode   string `json:"errorCode,omitempty"`
+
+	// NationalChecksum is the result of the national check digit validation
+	// (valid, invalid or unsupported). It doesn't influence Valid.
+	NationalChecksum string `json:"nationalChecksum,omitempty"`
+
+	// StructureValid is the validity of the IBAN itself when the validation
+	// also asserted the expected country
+	StructureValid *bool `json:"structureValid,omitempty"`
+
+	// Suggestions are corrections of an IBAN with a wrong checksum,
+	// returned with suggest=true
+	Suggestions []string `json:"suggestions,omitempty"`
+
+	// SepaReachability is returned with getBIC=true and
+	// includeSepaReachability=true when the bank data contains it
+	SepaReachability *SepaReachability `json:"sepaReachability,omitempty"`
+
+	// BicSource is the source of the BIC found with getBIC=true, e.g.
+	// "registry" or "derived", when the bank data contains it
+	BicSource string `json:"bicSource,omitempty"`
+
+	// QRIban tells whether a Swiss or Liechtenstein IBAN is a QR-IBAN, it
+	// is omitted for other countries
+	QRIban *bool `json:"qrIban,omitempty"`
+
+	// Components are the bank code, branch code and account number parsed
+	// from the BBAN, returned with includeComponents=true
+	Components *BbanComponents `json:"components,omitempty"`
+
+	// Debug is returned with debug=true
+	Debug *DebugInfo `json:"debug,omitempty"`
+}
+
+var (
+	countryNames     map[string]string
+	countryNamesOnce sync.Once
+)
+
+// Returns the name of the country with the ISO 3166 code countryCode. The
+// names are taken from goiban.COUNTRY_TO_CC_MAP, the data behind /countries.
+func countryName(countryCode string) string {
+	countryNamesOnce.Do(func() {
+		countryNames = make(map[string]string, len(goiban.COUNTRY_TO_CC_MAP))
+		for name, code := range goiban.COUNTRY_TO_CC_MAP {
+			countryNames[code] = name
+		}
+	})
+
+	return countryNames[countryCode]
+}
+
+// ErrorResponse is returned by endpoints which don't produce a validation result
+type ErrorResponse struct {
+	Valid     bool   `json:"valid"`
+	ErrorCode string `json:"errorCode"`
+	Message   string `json:"message"`
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime/debug"
+	"sync"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+// GOIBAN_MODULE is the module path of the goiban library
+const GOIBAN_MODULE = "github.com/fourcube/goiban"
+
+// Build information, set at build time with
+// -ldflags "-X main.version=... -X main.commit=... -X main.buildTime=..."
+var (
+	version   = "dev"
+	commit    = "unknown"
+	buildTime = "unknown"
+)
+
+// VersionInfo is the response of /version
+type VersionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"buildTime"`
+
+	// GoibanVersion is the version of the goiban library the service was
+	// built with
+	GoibanVersion string `json:"goibanVersion"`
+}
+
+// DebugInfo is added to validation results with debug=true
+type DebugInfo struct {
+	GoibanVersion string `json:"goibanVersion"`
+}
+
+var (
+	libraryVersion     string
+	libraryVersionOnce sync.Once
+)
+
+// Returns the module version of the goiban library from the build info,
+// e.g. v0.0.0-20200101000000-abcdef123456. A replaced module reports the
+// version of its replacement. Returns "unknown" for builds without module
+// information.
+func goibanVersion() string {
+	libraryVersionOnce.Do(func() {
+		libraryVersion = "unknown"
+
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+
+		for _, dep := range info.Deps {
+			if dep.Path != GOIBAN_MODULE {
+				continue
+			}
+
+			if dep.Replace != nil {
+				dep = dep.Replace
+			}
+			if dep.Version != "" {
+				libraryVersion = dep.Version
+			}
+		}
+	})
+
+	return libraryVersion
+}
+
+// Processes requests to the /version url. Reports which build is running.
+func versionHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	// Allow CORS
+	allowOrigin(w, r)
+
+	data, err := json.MarshalIndent(VersionInfo{version, commit, buildTime, goibanVersion()}, "", "  ")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeResponse(w, r, http.StatusOK, data)
+}
+
+// Adds the DebugInfo to the rendered validation result strRes
+func addDebugInfo(strRes string) string {
+	var result ValidationResponse
+	json.Unmarshal([]byte(strRes), &result)
+
+	result.Debug = &DebugInfo{GoibanVersion: goibanVersion()}
+
+	res, _ := json.MarshalIndent(result, "", "  ")
+	return string(res)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestVersionDefaultsToDev(t *testing.T) {
+	resp, err := http.Get(server.URL + "/version")
+	if err != nil {
+		t.Errorf("failed to get the version %v", err)
+		t.FailNow()
+	}
+	defer resp.Body.Close()
+
+	var info VersionInfo
+	json.NewDecoder(resp.Body).Decode(&info)
+
+	if info.Version != "dev" || info.Commit != "unknown" || info.BuildTime != "unknown" {
+		t.Errorf("unexpected build info %v", info)
+	}
+}
+
+func TestValidationDebugInfo(t *testing.T) {
+	resp, err := http.Get(server.URL + "/validate/DE89370400440532013000?debug=true")
+	if err != nil {
+		t.Errorf("failed to validate %v", err)
+		t.FailNow()
+	}
+	defer resp.Body.Close()
+
+	var result ValidationResponse
+	json.NewDecoder(resp.Body).Decode(&result)
+
+	if result.Debug == nil || result.Debug.GoibanVersion != goibanVersion() {
+		t.Errorf("expected the goiban version in the result, got %v", result.Debug)
+	}
+
+	if resp.Header.Get("X-Goiban-Version") != goibanVersion() {
+		t.Errorf("expected the X-Goiban-Version header, got %q", resp.Header.Get("X-Goiban-Version"))
+	}
+}
+
+func TestValidationWithoutDebugInfo(t *testing.T) {
+	resp, err := http.Get(server.URL + "/validate/DE89370400440532013000")
+	if err != nil {
+		t.Errorf("failed to validate %v", err)
+		t.FailNow()
+	}
+	defer resp.Body.Close()
+
+	var result ValidationResponse
+	json.NewDecoder(resp.Body).Decode(&result)
+
+	if result.Debug != nil || resp.Header.Get("X-Goiban-Version") != "" {
+		t.Errorf("expected no debug information without debug=true")
+	}
+}
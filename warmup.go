@@ -0,0 +1,34 @@
+package main
+
+import (
+	"log"
+	"strings"
+	"time"
+)
+
+// Preloads the caches before the server accepts requests. Failures are
+// logged, the service starts anyway.
+func warmup(config *Config) {
+	start := time.Now()
+
+	// builds the country name table used by every validation and the
+	// /countries response
+	countryName("")
+	countriesResponse()
+
+	// opens the first connection of the pool
+	err := withDBRetry(db.Ping)
+	if err != nil {
+		log.Printf("Warmup: database is unavailable: %v", err)
+		return
+	}
+
+	for _, countryCode := range config.WarmupBanks {
+		_, err = listBanks(strings.ToUpper(countryCode))
+		if err != nil {
+			log.Printf("Warmup: error while loading the banks of %v: %v", countryCode, err)
+		}
+	}
+
+	log.Printf("Warmup finished after %v", time.Since(start))
+}
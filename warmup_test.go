@@ -0,0 +1,16 @@
+package main
+
+import "testing"
+
+func TestWarmupPreloadsBanks(t *testing.T) {
+	if db.Ping() != nil {
+		t.Skip("database is not available")
+	}
+
+	c.Delete("banks:DE")
+	warmup(&Config{WarmupBanks: []string{"de"}})
+
+	if _, found := c.Get("banks:DE"); !found {
+		t.Errorf("expected the banks of DE to be cached")
+	}
+}